@@ -0,0 +1,93 @@
+package ai
+
+import (
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Evaluator scores a position that is still in progress from the point of
+// view of the side to move. Higher is better.
+type Evaluator interface {
+	Evaluate(p *rules.Position) int
+}
+
+// Weights is the built-in Evaluator: a weighted sum of material, how far
+// the king is from escaping and how many black pieces hem him in.
+type Weights struct {
+	BlackPawn int
+
+	// WhitePawn is usually worth more as white starts with fewer pieces.
+	WhitePawn int
+
+	// KingDistance is counted for every step between the king and the
+	// nearest square he escapes on.
+	KingDistance int
+
+	// KingRing is counted for every black piece on the eight squares
+	// around the king.
+	KingRing int
+}
+
+var DefaultWeights = Weights{
+	BlackPawn:    10,
+	WhitePawn:    20,
+	KingDistance: 6,
+	KingRing:     12,
+}
+
+func (w Weights) Evaluate(p *rules.Position) int {
+	score := w.BlackPawn*int(p.BlackPawns) - w.WhitePawn*int(p.WhitePawns)
+
+	if king, ok := p.KingSquare(); ok {
+		score += w.KingDistance*KingDistance(p, king) + w.KingRing*KingRing(p, king)
+	}
+
+	if p.BlacksTurn {
+		return score
+	}
+
+	return -score
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// KingDistance counts the orthogonal steps from the king on c to the
+// nearest corner, or the nearest edge when the king escapes on the edge.
+func KingDistance(p *rules.Position, c rules.Coord) int {
+	last := p.Size() - 1
+
+	if p.Rules.Escape == rules.EdgeEscape {
+		return min(c.Row, c.Col, last-c.Row, last-c.Col)
+	}
+
+	best := 2 * last
+
+	for _, corner := range p.Variant.Corners {
+		best = min(best, abs(corner.Row-c.Row)+abs(corner.Col-c.Col))
+	}
+
+	return best
+}
+
+// KingRing counts the black pieces on the eight squares around the king
+// on c.
+func KingRing(p *rules.Position, c rules.Coord) int {
+	n := 0
+
+	for row := c.Row - 1; row <= c.Row+1; row++ {
+		for col := c.Col - 1; col <= c.Col+1; col++ {
+			s := rules.Coord{Row: row, Col: col}
+
+			if p.InBounds(s) && p.IsBlack(s) {
+				n++
+			}
+		}
+	}
+
+	return n
+}
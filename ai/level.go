@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"fmt"
+	"time"
+)
+
+// Level is how strongly the computer plays.
+type Level uint8
+
+const (
+	Beginner Level = iota
+	Easy
+	Medium
+	Hard
+)
+
+var levelNames = [...]string{
+	Beginner: "beginner",
+	Easy:     "easy",
+	Medium:   "medium",
+	Hard:     "hard",
+}
+
+var Levels = [...]Level{Beginner, Easy, Medium, Hard}
+
+func (l Level) String() string {
+	if int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+
+	return "unknown"
+}
+
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if s == name {
+			return Level(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown level %q", s)
+}
+
+// Style is the computer's taste in positions.
+type Style uint8
+
+const (
+	Balanced Style = iota
+
+	// Aggressive cares most about closing in on the king.
+	Aggressive
+
+	// Runner cares most about getting the king to safety.
+	Runner
+)
+
+var styleNames = [...]string{
+	Balanced:   "balanced",
+	Aggressive: "aggressive",
+	Runner:     "runner",
+}
+
+var Styles = [...]Style{Balanced, Aggressive, Runner}
+
+func (s Style) String() string {
+	if int(s) < len(styleNames) {
+		return styleNames[s]
+	}
+
+	return "unknown"
+}
+
+func ParseStyle(s string) (Style, error) {
+	for i, name := range styleNames {
+		if s == name {
+			return Style(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown style %q", s)
+}
+
+func (s Style) Weights() Weights {
+	w := DefaultWeights
+
+	switch s {
+	case Aggressive:
+		w.KingRing *= 3
+		w.BlackPawn /= 2
+	case Runner:
+		w.KingDistance *= 3
+		w.WhitePawn /= 2
+	}
+
+	return w
+}
+
+// Searcher returns an alpha-beta searcher playing at level l in style s.
+// The weaker levels look less far ahead, wander from the best move and
+// now and then blunder.
+func (l Level) Searcher(s Style) *Searcher {
+	sr := NewSearcher()
+	sr.Eval = s.Weights()
+
+	switch l {
+	case Beginner:
+		sr.MaxDepth, sr.Time, sr.Noise, sr.Blunders = 1, time.Second, 40, 0.2
+	case Easy:
+		sr.MaxDepth, sr.Time, sr.Noise, sr.Blunders = 2, time.Second, 20, 0.05
+	case Medium:
+		sr.MaxDepth, sr.Time, sr.Noise = 3, time.Second, 8
+	}
+
+	return sr
+}
+
+// MCTS returns a Monte Carlo searcher playing at level l.
+func (l Level) MCTS() *MCTS {
+	m := NewMCTS()
+
+	switch l {
+	case Beginner:
+		m.Playouts = 100
+	case Easy:
+		m.Playouts = 500
+	case Medium:
+		m.Playouts = 2000
+	}
+
+	return m
+}
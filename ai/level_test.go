@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestLevels(t *testing.T) {
+	p := rules.NewPosition(rules.Brandubh)
+
+	for _, l := range Levels {
+		for _, style := range Styles {
+			s := l.Searcher(style)
+			s.MaxDepth = min(s.MaxDepth, 2)
+			s.Seed = 1
+
+			res, err := s.Search(context.Background(), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !p.IsLegal(res.Move) {
+				t.Errorf("%v %v: got illegal move %v", l, style, res.Move)
+			}
+		}
+
+		if parsed, err := ParseLevel(l.String()); err != nil || parsed != l {
+			t.Errorf("%v: parsed as %v, %v", l, parsed, err)
+		}
+	}
+}
+
+func TestNoiseStillTakesWins(t *testing.T) {
+	p := position(t, false,
+		"...k...",
+		".......",
+		"...b...",
+		".......",
+		".b.w.b.",
+		".......",
+		"...b...",
+	)
+
+	s := Beginner.Searcher(Balanced)
+	s.Blunders = 0
+	s.Seed = 1
+
+	res, err := s.Search(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Score != Win-1 {
+		t.Errorf("noise hid the win: got %v with score %v", res.Move, res.Score)
+	}
+}
@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// MCTS picks moves with Monte Carlo tree search: it grows a tree of
+// positions guided by UCT and scores each new leaf by playing random
+// moves to the end of the game.
+type MCTS struct {
+	// Playouts caps the number of playouts per search; 0 means no limit.
+	Playouts int
+
+	// Time is the budget for a single search; 0 means no limit. With
+	// neither Playouts nor Time set a search runs until ctx is cancelled.
+	Time time.Duration
+
+	// Exploration is the UCT constant; higher values try more moves.
+	Exploration float64
+
+	// PlayoutLength ends a playout as a draw after this many moves.
+	PlayoutLength int
+
+	// Seed makes searches repeatable; 0 seeds from the clock.
+	Seed int64
+}
+
+func NewMCTS() *MCTS {
+	return &MCTS{Time: 2 * time.Second, Exploration: math.Sqrt2, PlayoutLength: 200}
+}
+
+type node struct {
+	position rules.Position
+	move     rules.Move
+	parent   *node
+	children []*node
+	untried  []rules.Move
+
+	visits int
+
+	// wins is counted for the side that made move, draws counting half.
+	wins float64
+}
+
+func newNode(p rules.Position, m rules.Move, parent *node) *node {
+	n := &node{position: p, move: m, parent: parent}
+
+	if !p.Outcome().IsOver() {
+		n.untried = p.LegalMoves()
+	}
+
+	return n
+}
+
+func (n *node) uct(exploration float64) float64 {
+	return n.wins/float64(n.visits) + exploration*math.Sqrt(math.Log(float64(n.parent.visits))/float64(n.visits))
+}
+
+func (n *node) selectChild(exploration float64) *node {
+	best, bestScore := n.children[0], math.Inf(-1)
+
+	for _, c := range n.children {
+		if score := c.uct(exploration); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+
+	return best
+}
+
+// Search returns the most visited move after the playouts. The score is
+// the share of playouts the side to move won, in thousandths.
+func (m *MCTS) Search(ctx context.Context, p rules.Position) (Result, error) {
+	if len(p.LegalMoves()) == 0 {
+		return Result{}, ErrNoMoves
+	}
+
+	if m.Time > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Time)
+		defer cancel()
+	}
+
+	seed := m.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	root := newNode(p, rules.Move{}, nil)
+	playouts := 0
+
+	// Like Searcher, always do some work so there is a move to play.
+	for playouts == 0 || ctx.Err() == nil {
+		if m.Playouts > 0 && playouts >= m.Playouts {
+			break
+		}
+
+		n := root
+		for len(n.untried) == 0 && len(n.children) > 0 {
+			n = n.selectChild(m.Exploration)
+		}
+
+		if len(n.untried) > 0 {
+			i := rnd.Intn(len(n.untried))
+			move := n.untried[i]
+			n.untried[i] = n.untried[len(n.untried)-1]
+			n.untried = n.untried[:len(n.untried)-1]
+
+			child := n.position
+			child.Apply(move)
+
+			c := newNode(child, move, n)
+			n.children = append(n.children, c)
+			n = c
+		}
+
+		outcome := m.playout(n.position, rnd)
+
+		for ; n != nil; n = n.parent {
+			n.visits++
+
+			if n.parent == nil {
+				continue
+			}
+
+			switch winner, ok := outcome.Winner(); {
+			case !ok:
+				n.wins += 0.5
+			case winner == n.parent.position.ToMove():
+				n.wins++
+			}
+		}
+
+		playouts++
+	}
+
+	best := root.children[0]
+	for _, c := range root.children {
+		if c.visits > best.visits {
+			best = c
+		}
+	}
+
+	return Result{
+		Move:  best.move,
+		Score: int(1000 * best.wins / float64(best.visits)),
+		Nodes: uint64(playouts),
+	}, nil
+}
+
+// playout plays random moves from p until the game ends.
+func (m *MCTS) playout(p rules.Position, rnd *rand.Rand) rules.Outcome {
+	for i := 0; m.PlayoutLength == 0 || i < m.PlayoutLength; i++ {
+		if p.Outcome().IsOver() {
+			return p.Outcome()
+		}
+
+		moves := p.LegalMoves()
+		p.Apply(moves[rnd.Intn(len(moves))])
+	}
+
+	if p.Outcome().IsOver() {
+		return p.Outcome()
+	}
+
+	return rules.Outcome{Result: rules.Draw, Reason: rules.ReasonMoveLimit}
+}
@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestMCTSFindsEscape(t *testing.T) {
+	p := position(t, false,
+		"...k...",
+		".......",
+		"...b...",
+		".......",
+		".b.w.b.",
+		".......",
+		"...b...",
+	)
+
+	m := NewMCTS()
+	m.Playouts = 2000
+	m.Time = 0
+	m.Seed = 1
+
+	res, err := m.Search(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Move.From != (rules.Coord{Row: 0, Col: 3}) || res.Move.To.Row != 0 || (res.Move.To.Col != 0 && res.Move.To.Col != 6) {
+		t.Errorf("got %v, want the king to escape", res.Move)
+	}
+
+	if res.Nodes != 2000 {
+		t.Errorf("ran %v playouts, want 2000", res.Nodes)
+	}
+}
+
+func TestMCTSIsRepeatable(t *testing.T) {
+	m := NewMCTS()
+	m.Playouts = 200
+	m.Time = 0
+	m.Seed = 7
+
+	first, err := m.Search(context.Background(), rules.NewPosition(rules.Brandubh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, _ := m.Search(context.Background(), rules.NewPosition(rules.Brandubh))
+	if first != second {
+		t.Errorf("got %v then %v from the same seed", first, second)
+	}
+}
@@ -0,0 +1,415 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+var ErrNoMoves = errors.New("ai: no legal moves")
+
+const (
+	// Win is the score of a won position. Wins found sooner score higher.
+	Win = 1_000_000
+
+	infinity = Win + 1
+
+	// maxPly bounds how deep a search can go.
+	maxPly = 128
+
+	// checkEvery is how many nodes are searched between looks at the clock.
+	checkEvery = 1024
+)
+
+// Engine is anything that picks moves. Search may be called again while
+// a cancelled search is still winding down.
+type Engine interface {
+	Search(ctx context.Context, p rules.Position) (Result, error)
+}
+
+// Result describes the move a search settled on.
+type Result struct {
+	Move  rules.Move
+	Score int
+	Depth int
+	Nodes uint64
+}
+
+// Searcher looks for the best move with alpha-beta search, deepening one
+// ply at a time until it runs out of time or reaches MaxDepth.
+type Searcher struct {
+	Eval     Evaluator
+	MaxDepth int
+
+	// Time is the budget for a single search; 0 means no limit.
+	Time time.Duration
+
+	// Table remembers positions between iterations and searches; nil
+	// turns it off.
+	Table *Table
+
+	// Ordering tries killer moves and moves with a good history first.
+	Ordering bool
+
+	// Workers searches with this many goroutines sharing Table, each
+	// starting from a different depth and move order (Lazy SMP). Only the
+	// first worker's answer is used; the others fill the table for it.
+	// With 0 or 1 searches are single-threaded and repeatable.
+	Workers int
+
+	// Noise adds up to this much to the score of every move at the root,
+	// so weaker players do not always pick the same one.
+	Noise int
+
+	// Blunders is the chance of playing a random move instead.
+	Blunders float64
+
+	// Seed makes Noise and Blunders repeatable; 0 seeds from the clock.
+	Seed int64
+}
+
+// search holds the state of a single call to Searcher.Search.
+type search struct {
+	*Searcher
+
+	ctx     context.Context
+	nodes   uint64
+	stopped bool
+
+	rnd     *rand.Rand
+	size    int
+	killers [maxPly][2]rules.Move
+
+	// history scores each move by side, from square and to square.
+	history []int
+}
+
+func NewSearcher() *Searcher {
+	return &Searcher{
+		Eval:     DefaultWeights,
+		MaxDepth: 64,
+		Time:     2 * time.Second,
+		Table:    NewTable(64),
+		Ordering: true,
+	}
+}
+
+// Search returns the best move found for the side to move in p. It stops
+// early when ctx is cancelled, but always searches at least one ply.
+func (sr *Searcher) Search(ctx context.Context, p rules.Position) (Result, error) {
+	moves := p.LegalMoves()
+	if len(moves) == 0 {
+		return Result{}, ErrNoMoves
+	}
+
+	if sr.Time > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sr.Time)
+		defer cancel()
+	}
+
+	if sr.Table != nil {
+		sr.Table.NewSearch()
+	}
+
+	helpers, stopHelpers := context.WithCancel(ctx)
+	defer stopHelpers()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var nodes uint64
+
+	for id := 1; id < sr.Workers; id++ {
+		shuffled := make([]rules.Move, len(moves))
+		copy(shuffled, moves[id%len(moves):])
+		copy(shuffled[len(moves)-id%len(moves):], moves)
+
+		wg.Add(1)
+
+		go func(id int, moves []rules.Move) {
+			defer wg.Done()
+
+			s := sr.newSearch(p)
+			s.ctx = helpers
+			s.deepen(helpers, p, moves, 1+id%2)
+
+			mu.Lock()
+			nodes += s.nodes
+			mu.Unlock()
+		}(id, shuffled)
+	}
+
+	s := sr.newSearch(p)
+	best := s.deepen(ctx, p, moves, 1)
+
+	stopHelpers()
+	wg.Wait()
+	best.Nodes = s.nodes + nodes
+
+	if s.Blunders > 0 && s.rnd.Float64() < s.Blunders {
+		best.Move = moves[s.rnd.Intn(len(moves))]
+	}
+
+	return best, nil
+}
+
+func (sr *Searcher) newSearch(p rules.Position) *search {
+	seed := sr.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	s := &search{Searcher: sr, rnd: rand.New(rand.NewSource(seed)), size: p.Size()}
+	if s.Ordering {
+		s.history = make([]int, 2*s.size*s.size*s.size*s.size)
+	}
+
+	return s
+}
+
+// deepen searches one ply deeper at a time from the given depth. Unless
+// the search already has a context the first ply always finishes so there
+// is a move to play.
+func (s *search) deepen(ctx context.Context, p rules.Position, moves []rules.Move, depth int) Result {
+	best := Result{Move: moves[0]}
+
+	for ; depth <= min(max(s.MaxDepth, 1), maxPly-1); depth++ {
+		move, score := s.searchRoot(&p, moves, depth)
+		if s.stopped {
+			break
+		}
+
+		best = Result{Move: move, Score: score, Depth: depth}
+
+		if score >= Win-depth || score <= -Win+depth {
+			break
+		}
+
+		s.ctx = ctx
+		if s.shouldStop() {
+			break
+		}
+
+		moves = bestFirst(moves, move)
+	}
+
+	return best
+}
+
+func bestFirst(moves []rules.Move, best rules.Move) []rules.Move {
+	for i, m := range moves {
+		if m == best {
+			moves[0], moves[i] = moves[i], moves[0]
+			break
+		}
+	}
+
+	return moves
+}
+
+func (s *search) shouldStop() bool {
+	if s.ctx != nil && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+
+	return s.stopped
+}
+
+// searchRoot returns the best move and its score. With Noise set, moves
+// that come within Noise of the best are searched exactly enough to
+// compete once their noise is added.
+func (s *search) searchRoot(p *rules.Position, moves []rules.Move, depth int) (rules.Move, int) {
+	best, bestScore, bestNoisy, alpha := moves[0], -infinity, -infinity, -infinity
+
+	for _, m := range moves {
+		child := *p
+		child.Apply(m)
+
+		score := -s.negamax(&child, depth-1, -infinity, -(alpha - s.Noise), 1)
+		if s.stopped {
+			break
+		}
+
+		noisy := score
+		if s.Noise > 0 {
+			noisy += s.rnd.Intn(s.Noise + 1)
+		}
+
+		if noisy > bestNoisy {
+			best, bestScore, bestNoisy = m, score, noisy
+		}
+
+		alpha = max(alpha, score)
+	}
+
+	return best, bestScore
+}
+
+func (s *search) negamax(p *rules.Position, depth int, alpha int, beta int, ply int) int {
+	s.nodes++
+	if s.nodes%checkEvery == 0 && s.shouldStop() {
+		return 0
+	}
+
+	if outcome := p.Outcome(); outcome.IsOver() {
+		return terminal(p, outcome, ply)
+	}
+
+	if depth == 0 {
+		return s.Eval.Evaluate(p)
+	}
+
+	key := p.Hash()
+	var hashMove *rules.Move
+
+	if s.Table != nil {
+		if e, ok := s.Table.probe(key); ok {
+			m := e.move(s.size)
+			hashMove = &m
+
+			if int(e.depth) >= depth {
+				score := fromTable(e.score, ply)
+
+				switch {
+				case e.bound == exactBound,
+					e.bound == lowerBound && score >= beta,
+					e.bound == upperBound && score <= alpha:
+					return score
+				}
+			}
+		}
+	}
+
+	moves := p.LegalMoves()
+	s.order(p, moves, hashMove, ply)
+
+	best, bestMove, alphaWas := -infinity, moves[0], alpha
+
+	for _, m := range moves {
+		child := *p
+		child.Apply(m)
+
+		score := -s.negamax(&child, depth-1, -beta, -alpha, ply+1)
+		if s.stopped {
+			return 0
+		}
+
+		if score > best {
+			best, bestMove = score, m
+		}
+
+		if score > alpha {
+			alpha = score
+		}
+
+		if alpha >= beta {
+			s.cutoff(p, m, depth, ply)
+			break
+		}
+	}
+
+	if s.Table != nil {
+		b := exactBound
+
+		switch {
+		case best <= alphaWas:
+			b = upperBound
+		case best >= beta:
+			b = lowerBound
+		}
+
+		s.Table.store(key, ttEntry{
+			score: toTable(best, ply),
+			from:  uint16(bestMove.From.Row*s.size + bestMove.From.Col),
+			to:    uint16(bestMove.To.Row*s.size + bestMove.To.Col),
+			depth: uint8(depth),
+			bound: b,
+		})
+	}
+
+	return best
+}
+
+func (s *search) historyIndex(p *rules.Position, m rules.Move) int {
+	squares := s.size * s.size
+	from := m.From.Row*s.size + m.From.Col
+	to := m.To.Row*s.size + m.To.Col
+
+	return (int(p.ToMove())*squares+from)*squares + to
+}
+
+// cutoff remembers a move that refuted its position so it is tried early
+// in its siblings and wherever else it turns up.
+func (s *search) cutoff(p *rules.Position, m rules.Move, depth int, ply int) {
+	if !s.Ordering {
+		return
+	}
+
+	if s.killers[ply][0] != m {
+		s.killers[ply][1] = s.killers[ply][0]
+		s.killers[ply][0] = m
+	}
+
+	s.history[s.historyIndex(p, m)] += depth * depth
+}
+
+type orderedMoves struct {
+	moves  []rules.Move
+	scores []int
+}
+
+func (o orderedMoves) Len() int           { return len(o.moves) }
+func (o orderedMoves) Less(i, j int) bool { return o.scores[i] > o.scores[j] }
+func (o orderedMoves) Swap(i, j int) {
+	o.moves[i], o.moves[j] = o.moves[j], o.moves[i]
+	o.scores[i], o.scores[j] = o.scores[j], o.scores[i]
+}
+
+// order puts the table's best move first, then the killers, then the rest
+// by their history.
+func (s *search) order(p *rules.Position, moves []rules.Move, hashMove *rules.Move, ply int) {
+	if hashMove != nil && !s.Ordering {
+		bestFirst(moves, *hashMove)
+		return
+	}
+
+	if !s.Ordering {
+		return
+	}
+
+	scores := make([]int, len(moves))
+
+	for i, m := range moves {
+		switch {
+		case hashMove != nil && m == *hashMove:
+			scores[i] = 1 << 30
+		case m == s.killers[ply][0]:
+			scores[i] = 1 << 29
+		case m == s.killers[ply][1]:
+			scores[i] = 1 << 28
+		default:
+			scores[i] = s.history[s.historyIndex(p, m)]
+		}
+	}
+
+	sort.Stable(orderedMoves{moves: moves, scores: scores})
+}
+
+// terminal scores a finished game for the side to move.
+func terminal(p *rules.Position, outcome rules.Outcome, ply int) int {
+	winner, ok := outcome.Winner()
+	if !ok {
+		return 0
+	}
+
+	if winner == p.ToMove() {
+		return Win - ply
+	}
+
+	return -Win + ply
+}
@@ -0,0 +1,207 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func position(t *testing.T, blacksTurn bool, setup ...string) rules.Position {
+	t.Helper()
+
+	v, err := rules.NewVariant("test", "Test", rules.RuleSet{KingCapture: rules.KingCapturedOnFourSides}, setup...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := rules.NewPosition(v)
+	if !blacksTurn {
+		p.SetToMove(rules.White)
+	}
+
+	return p
+}
+
+func TestSearchFindsWins(t *testing.T) {
+	tests := []struct {
+		name       string
+		blacksTurn bool
+		setup      []string
+		want       []rules.Move
+	}{
+		{
+			name:       "king escapes",
+			blacksTurn: false,
+			setup: []string{
+				"...k...",
+				".......",
+				"...b...",
+				".......",
+				".b.w.b.",
+				".......",
+				"...b...",
+			},
+			want: []rules.Move{
+				{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 0}},
+				{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 6}},
+			},
+		},
+		{
+			name:       "king captured",
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				"...b...",
+				"..bk...",
+				"...b...",
+				"w......",
+				"....b..",
+				".......",
+			},
+			want: []rules.Move{
+				{From: rules.Coord{Row: 5, Col: 4}, To: rules.Coord{Row: 2, Col: 4}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, workers := range []int{1, 4} {
+			t.Run(fmt.Sprintf("%v/%v workers", tt.name, workers), func(t *testing.T) {
+				s := NewSearcher()
+				s.MaxDepth = 3
+				s.Time = 0
+				s.Workers = workers
+
+				res, err := s.Search(context.Background(), position(t, tt.blacksTurn, tt.setup...))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if res.Score != Win-1 {
+					t.Errorf("got score %v, want %v", res.Score, Win-1)
+				}
+
+				for _, m := range tt.want {
+					if res.Move == m {
+						return
+					}
+				}
+
+				t.Errorf("got %v, want one of %v", res.Move, tt.want)
+			})
+		}
+	}
+}
+
+func TestSearchStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := NewSearcher()
+	res, err := s.Search(ctx, rules.NewPosition(rules.Copenhagen))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Depth != 1 {
+		t.Errorf("searched to depth %v after being cancelled", res.Depth)
+	}
+}
+
+func TestSingleThreadIsRepeatable(t *testing.T) {
+	var results []Result
+
+	for i := 0; i < 2; i++ {
+		s := NewSearcher()
+		s.MaxDepth = 3
+		s.Time = 0
+		s.Workers = 1
+
+		res, err := s.Search(context.Background(), rules.NewPosition(rules.Tablut))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results = append(results, res)
+	}
+
+	if results[0] != results[1] {
+		t.Errorf("got %v then %v", results[0], results[1])
+	}
+}
+
+func TestParallelSearchPlaysLegalMove(t *testing.T) {
+	s := NewSearcher()
+	s.Time = 200 * time.Millisecond
+	s.Workers = 4
+
+	p := rules.NewPosition(rules.Copenhagen)
+
+	res, err := s.Search(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.IsLegal(res.Move) {
+		t.Errorf("got illegal move %v", res.Move)
+	}
+}
+
+func TestEvaluateIsSymmetric(t *testing.T) {
+	p := rules.NewPosition(rules.Copenhagen)
+	black := DefaultWeights.Evaluate(&p)
+
+	p.SetToMove(rules.White)
+	if white := DefaultWeights.Evaluate(&p); white != -black {
+		t.Errorf("black scores %v, white %v", black, white)
+	}
+}
+
+// BenchmarkSearch reports how many nodes each improvement to the search
+// needs to look at the opening to a fixed depth.
+func BenchmarkSearch(b *testing.B) {
+	configs := []struct {
+		name     string
+		table    bool
+		ordering bool
+	}{
+		{"plain", false, false},
+		{"table", true, false},
+		{"table+ordering", true, true},
+	}
+
+	for _, c := range configs {
+		b.Run(c.name, func(b *testing.B) {
+			s := NewSearcher()
+			s.MaxDepth = 4
+			s.Time = 0
+			s.Ordering = c.ordering
+
+			if !c.table {
+				s.Table = nil
+			}
+
+			var nodes uint64
+
+			for i := 0; i < b.N; i++ {
+				if s.Table != nil {
+					b.StopTimer()
+					s.Table.Clear()
+					b.StartTimer()
+				}
+
+				res, err := s.Search(context.Background(), rules.NewPosition(rules.Copenhagen))
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				nodes += res.Nodes
+			}
+
+			b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package ai
+
+import (
+	"sync/atomic"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+type bound uint8
+
+const (
+	exactBound bound = iota
+	lowerBound
+	upperBound
+)
+
+// ttEntry is what the table remembers about a position.
+type ttEntry struct {
+	score int32
+	from  uint16
+	to    uint16
+	depth uint8
+	bound bound
+	age   uint8
+}
+
+// pack squeezes an entry into one word: 32 bits of score, 9 bits each for
+// the squares of the best move, 8 bits of depth, 2 of bound and 4 of age.
+func (e ttEntry) pack() uint64 {
+	return uint64(uint32(e.score)) |
+		uint64(e.from&0x1ff)<<32 |
+		uint64(e.to&0x1ff)<<41 |
+		uint64(e.depth)<<50 |
+		uint64(e.bound&0x3)<<58 |
+		uint64(e.age&0xf)<<60
+}
+
+func unpack(data uint64) ttEntry {
+	return ttEntry{
+		score: int32(uint32(data)),
+		from:  uint16(data>>32) & 0x1ff,
+		to:    uint16(data>>41) & 0x1ff,
+		depth: uint8(data >> 50),
+		bound: bound(data>>58) & 0x3,
+		age:   uint8(data>>60) & 0xf,
+	}
+}
+
+func (e ttEntry) move(size int) rules.Move {
+	return rules.Move{
+		From: rules.Coord{Row: int(e.from) / size, Col: int(e.from) % size},
+		To:   rules.Coord{Row: int(e.to) / size, Col: int(e.to) % size},
+	}
+}
+
+// Table is a fixed-size transposition table keyed by Zobrist hash. When
+// two positions share a slot the one searched deeper is kept, unless the
+// other is left over from an earlier search. It is safe to share between
+// searches running at the same time without locking.
+type Table struct {
+	// Each slot holds the key xor the data, then the data, so a slot
+	// whose words do not belong together never matches a key.
+	slots [][2]uint64
+	mask  uint64
+	age   atomic.Uint32
+}
+
+// NewTable makes a table taking up about the given number of megabytes.
+func NewTable(megabytes int) *Table {
+	n := uint64(1)
+	for n*2*16 <= uint64(megabytes)<<20 {
+		n *= 2
+	}
+
+	return &Table{slots: make([][2]uint64, n), mask: n - 1}
+}
+
+// NewSearch marks what is already in the table as old.
+func (t *Table) NewSearch() {
+	t.age.Add(1)
+}
+
+// Clear empties the table. It must not be used while a search is running.
+func (t *Table) Clear() {
+	clear(t.slots)
+	t.age.Store(0)
+}
+
+func (t *Table) probe(key uint64) (ttEntry, bool) {
+	slot := &t.slots[key&t.mask]
+	check, data := atomic.LoadUint64(&slot[0]), atomic.LoadUint64(&slot[1])
+
+	if check^data != key {
+		return ttEntry{}, false
+	}
+
+	return unpack(data), true
+}
+
+func (t *Table) store(key uint64, e ttEntry) {
+	slot := &t.slots[key&t.mask]
+	check, data := atomic.LoadUint64(&slot[0]), atomic.LoadUint64(&slot[1])
+	old := unpack(data)
+	e.age = uint8(t.age.Load()) & 0xf
+
+	if data != 0 && old.age == e.age && old.depth > e.depth && check^data != key {
+		return
+	}
+
+	atomic.StoreUint64(&slot[0], key^e.pack())
+	atomic.StoreUint64(&slot[1], e.pack())
+}
+
+// Wins are stored as distances from the position rather than the root so
+// they stay right wherever the position turns up in the tree.
+func toTable(score int, ply int) int32 {
+	switch {
+	case score > Win-maxPly:
+		score += ply
+	case score < -Win+maxPly:
+		score -= ply
+	}
+
+	return int32(score)
+}
+
+func fromTable(score int32, ply int) int {
+	s := int(score)
+
+	switch {
+	case s > Win-maxPly:
+		s -= ply
+	case s < -Win+maxPly:
+		s += ply
+	}
+
+	return s
+}
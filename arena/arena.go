@@ -0,0 +1,126 @@
+// Package arena plays matches between engines without a window, for
+// comparing settings and evaluation weights.
+package arena
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Game is the record of one game of a match.
+type Game struct {
+	Number  int
+	Variant *rules.Variant
+
+	// Black and White are the names of the entrants that played them.
+	Black string
+	White string
+
+	Moves   []rules.Move
+	Outcome rules.Outcome
+}
+
+// Match plays Games games between A and B. Games come in pairs on the
+// same variant and opening, with the entrants swapping sides, and the
+// pairs take turns through Variants.
+type Match struct {
+	A, B     *Entrant
+	Variants []*rules.Variant
+	Games    int
+
+	// RandomPlies starts each pair of games with this many random moves,
+	// so that engines which always pick the same move still play
+	// different games.
+	RandomPlies int
+
+	// MaxMoves draws games that go on this long on variants without a
+	// move limit of their own; 0 leaves them be.
+	MaxMoves int
+
+	// Seed picks the random openings.
+	Seed int64
+
+	// Played, if set, is told about each game as it ends.
+	Played func(g Game, s Score)
+}
+
+// Run plays the match and returns the score from A's point of view.
+func (m *Match) Run(ctx context.Context) (Score, error) {
+	var score Score
+
+	if len(m.Variants) == 0 {
+		return score, fmt.Errorf("arena: no variants to play")
+	}
+
+	for i := 0; i < m.Games; i++ {
+		v := m.Variants[i/2%len(m.Variants)]
+		opening := rand.New(rand.NewSource(m.Seed + int64(i/2)))
+
+		black, white := m.A, m.B
+		if i%2 == 1 {
+			black, white = m.B, m.A
+		}
+
+		g, err := m.play(ctx, v, opening, black, white)
+		if err != nil {
+			return score, fmt.Errorf("arena: game %v: %w", i+1, err)
+		}
+
+		g.Number = i + 1
+
+		switch winner, ok := g.Outcome.Winner(); {
+		case !ok:
+			score.Draws++
+		case (winner == rules.Black) == (black == m.A):
+			score.Wins++
+		default:
+			score.Losses++
+		}
+
+		if m.Played != nil {
+			m.Played(g, score)
+		}
+	}
+
+	return score, nil
+}
+
+func (m *Match) play(ctx context.Context, v *rules.Variant, opening *rand.Rand, black, white *Entrant) (Game, error) {
+	g := Game{Variant: v, Black: black.Name, White: white.Name}
+
+	p := rules.NewPosition(v)
+	if p.Rules.MoveLimit == 0 {
+		p.Rules.MoveLimit = m.MaxMoves
+	}
+
+	entrants := [2]*Entrant{rules.Black: black, rules.White: white}
+
+	for !p.Outcome().IsOver() {
+		mover := entrants[p.ToMove()]
+
+		var move rules.Move
+
+		if p.MoveCount < m.RandomPlies {
+			moves := p.LegalMoves()
+			move = moves[opening.Intn(len(moves))]
+		} else {
+			var err error
+			if move, err = mover.Player.NextMove(ctx, p); err != nil {
+				return g, err
+			}
+		}
+
+		if err := p.Apply(move); err != nil {
+			return g, fmt.Errorf("%v played %v: %w", mover.Name, rules.FormatMove(move, v.Size), err)
+		}
+
+		g.Moves = append(g.Moves, move)
+	}
+
+	g.Outcome = p.Outcome()
+
+	return g, nil
+}
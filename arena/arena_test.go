@@ -0,0 +1,94 @@
+package arena
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestElo(t *testing.T) {
+	tests := []struct {
+		score        Score
+		diff, margin float64
+	}{
+		{Score{Wins: 10, Draws: 0, Losses: 10}, 0, 163},
+		{Score{Wins: 75, Draws: 0, Losses: 25}, 191, 81},
+		{Score{Wins: 30, Draws: 40, Losses: 30}, 0, 53},
+		{Score{Wins: 0, Draws: 0, Losses: 5}, math.Inf(-1), math.Inf(1)},
+	}
+
+	for _, tt := range tests {
+		diff, margin := tt.score.Elo()
+
+		if math.Round(diff) != tt.diff || math.Round(margin) != tt.margin {
+			t.Errorf("%+v: got %.1f ± %.1f, want %v ± %v", tt.score, diff, margin, tt.diff, tt.margin)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	a, err := ParseEntrant("alphabeta,depth=1,time=0,seed=1,name=a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ParseEntrant("alphabeta,level=beginner,seed=2,king-ring=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var games []Game
+
+	m := &Match{
+		A:           a,
+		B:           b,
+		Variants:    []*rules.Variant{rules.Brandubh, rules.Tablut},
+		Games:       4,
+		RandomPlies: 2,
+		MaxMoves:    100,
+		Played:      func(g Game, s Score) { games = append(games, g) },
+	}
+
+	score, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if score.Games() != 4 || len(games) != 4 {
+		t.Fatalf("played %v games, recorded %v", score.Games(), len(games))
+	}
+
+	for i, g := range games {
+		wantBlack, wantVariant := "a", rules.Brandubh
+		if i%2 == 1 {
+			wantBlack = b.Name
+		}
+
+		if i >= 2 {
+			wantVariant = rules.Tablut
+		}
+
+		if g.Black != wantBlack || g.Variant != wantVariant || !g.Outcome.IsOver() {
+			t.Errorf("game %v: %v on %v, %v", g.Number, g.Black, g.Variant.ID, g.Outcome)
+		}
+	}
+
+	// Both games of a pair open the same way.
+	for i := 0; i < 4; i += 2 {
+		for ply := 0; ply < 2; ply++ {
+			if games[i].Moves[ply] != games[i+1].Moves[ply] {
+				t.Errorf("games %v and %v open differently", i+1, i+2)
+			}
+		}
+	}
+}
+
+func TestParseEntrant(t *testing.T) {
+	for _, spec := range []string{"minimax", "alphabeta,depth", "alphabeta,playouts=3", "mcts,depth=3", "alphabeta,level=grandmaster", "otep"} {
+		if _, err := ParseEntrant(spec); err == nil {
+			t.Errorf("%q parsed", spec)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package arena
+
+import (
+	"fmt"
+	"math"
+)
+
+// Score counts the results of a match from the first entrant's point of
+// view.
+type Score struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+func (s Score) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Points is the share of the points won, counting a draw as half a win.
+func (s Score) Points() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// Elo returns the rating difference the score suggests and the margin of
+// its 95% confidence interval. A clean sweep has no finite difference and
+// comes back as an infinity, as does the margin of a score too close to
+// one to pin down.
+func (s Score) Elo() (diff float64, margin float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0, math.Inf(1)
+	}
+
+	points := s.Points()
+
+	deviation := float64(s.Wins)*(1-points)*(1-points) +
+		float64(s.Draws)*(0.5-points)*(0.5-points) +
+		float64(s.Losses)*points*points
+	stderr := math.Sqrt(deviation/n) / math.Sqrt(n)
+
+	low := eloDiff(points - 1.96*stderr)
+	high := eloDiff(points + 1.96*stderr)
+
+	if math.IsInf(low, 0) || math.IsInf(high, 0) {
+		return eloDiff(points), math.Inf(1)
+	}
+
+	return eloDiff(points), (high - low) / 2
+}
+
+// eloDiff is the rating difference at which the stronger player is
+// expected to take this share of the points.
+func eloDiff(points float64) float64 {
+	switch {
+	case points <= 0:
+		return math.Inf(-1)
+	case points >= 1:
+		return math.Inf(1)
+	}
+
+	return 400 * math.Log10(points/(1-points))
+}
+
+func (s Score) String() string {
+	diff, margin := s.Elo()
+
+	return fmt.Sprintf("+%v =%v -%v, Elo %+.0f ± %.0f", s.Wins, s.Draws, s.Losses, diff, margin)
+}
@@ -0,0 +1,192 @@
+package arena
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	otep "github.com/technologyfreak/hnefatafl/otep"
+	player "github.com/technologyfreak/hnefatafl/player"
+)
+
+// Entrant is one side of a match.
+type Entrant struct {
+	Name   string
+	Player player.Player
+
+	close func() error
+}
+
+// Close stops an external engine; it does nothing for the built-in ones.
+func (e *Entrant) Close() error {
+	if e.close == nil {
+		return nil
+	}
+
+	return e.close()
+}
+
+// ParseEntrant makes an entrant from a spec such as
+//
+//	alphabeta,level=hard,depth=4,king-ring=20
+//	mcts,playouts=1000
+//	otep,cmd=./my-engine --quiet
+//
+// naming the engine and then changing its settings. The alphabeta engine
+// takes level, style, depth, time, workers, seed and the evaluation
+// weights black-pawn, white-pawn, king-distance and king-ring; mcts takes
+// level, playouts, time and seed; otep takes the cmd to run. Any of them
+// takes a name for the reports, which is otherwise the spec itself.
+func ParseEntrant(spec string) (*Entrant, error) {
+	engine, rest, _ := strings.Cut(spec, ",")
+
+	settings := map[string]string{"level": "hard", "style": "balanced"}
+	var order []string
+
+	if rest != "" {
+		for _, setting := range strings.Split(rest, ",") {
+			key, value, ok := strings.Cut(setting, "=")
+			if !ok {
+				return nil, fmt.Errorf("arena: %q: setting %q has no value", spec, setting)
+			}
+
+			key = strings.TrimSpace(key)
+			settings[key] = strings.TrimSpace(value)
+			order = append(order, key)
+		}
+	}
+
+	e := &Entrant{Name: spec}
+	if name, ok := settings["name"]; ok {
+		e.Name = name
+	}
+
+	level, err := ai.ParseLevel(settings["level"])
+	if err != nil {
+		return nil, fmt.Errorf("arena: %q: %w", spec, err)
+	}
+
+	style, err := ai.ParseStyle(settings["style"])
+	if err != nil {
+		return nil, fmt.Errorf("arena: %q: %w", spec, err)
+	}
+
+	switch engine {
+	case "alphabeta":
+		searcher := level.Searcher(style)
+		searcher.Workers = 1
+		err = configure(spec, order, settings, func(key, value string) (bool, error) {
+			return setSearcher(searcher, key, value)
+		})
+		e.Player = player.NewEngine(searcher)
+	case "mcts":
+		mcts := level.MCTS()
+		err = configure(spec, order, settings, func(key, value string) (bool, error) {
+			return setMCTS(mcts, key, value)
+		})
+		e.Player = player.NewEngine(mcts)
+	case "otep":
+		args := strings.Fields(settings["cmd"])
+		if len(args) == 0 {
+			return nil, fmt.Errorf("arena: %q: otep needs a cmd", spec)
+		}
+
+		var c *otep.Client
+		if c, err = otep.Start(args[0], args[1:]...); err != nil {
+			return nil, err
+		}
+
+		e.Player, e.close = c, c.Close
+	default:
+		return nil, fmt.Errorf("arena: %q: unknown engine %q", spec, engine)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// configure hands each setting to set, in the order they were given,
+// apart from the ones every engine understands.
+func configure(spec string, order []string, settings map[string]string, set func(key, value string) (bool, error)) error {
+	for _, key := range order {
+		switch key {
+		case "name", "level", "style":
+			continue
+		}
+
+		ok, err := set(key, settings[key])
+		if err != nil {
+			return fmt.Errorf("arena: %q: %v: %w", spec, key, err)
+		}
+
+		if !ok {
+			return fmt.Errorf("arena: %q: unknown setting %q", spec, key)
+		}
+	}
+
+	return nil
+}
+
+func setSearcher(sr *ai.Searcher, key, value string) (bool, error) {
+	var err error
+
+	switch key {
+	case "depth":
+		sr.MaxDepth, err = strconv.Atoi(value)
+	case "time":
+		sr.Time, err = time.ParseDuration(value)
+	case "workers":
+		sr.Workers, err = strconv.Atoi(value)
+	case "seed":
+		sr.Seed, err = strconv.ParseInt(value, 10, 64)
+	case "black-pawn", "white-pawn", "king-distance", "king-ring":
+		w, ok := sr.Eval.(ai.Weights)
+		if !ok {
+			w = ai.DefaultWeights
+		}
+
+		var n int
+		if n, err = strconv.Atoi(value); err != nil {
+			break
+		}
+
+		switch key {
+		case "black-pawn":
+			w.BlackPawn = n
+		case "white-pawn":
+			w.WhitePawn = n
+		case "king-distance":
+			w.KingDistance = n
+		case "king-ring":
+			w.KingRing = n
+		}
+
+		sr.Eval = w
+	default:
+		return false, nil
+	}
+
+	return true, err
+}
+
+func setMCTS(m *ai.MCTS, key, value string) (bool, error) {
+	var err error
+
+	switch key {
+	case "playouts":
+		m.Playouts, err = strconv.Atoi(value)
+	case "time":
+		m.Time, err = time.ParseDuration(value)
+	case "seed":
+		m.Seed, err = strconv.ParseInt(value, 10, 64)
+	default:
+		return false, nil
+	}
+
+	return true, err
+}
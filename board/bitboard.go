@@ -0,0 +1,292 @@
+package board
+
+import (
+	"math/bits"
+)
+
+const (
+	MaxSquaresPerRow = 19
+	bitboardWords    = (MaxSquaresPerRow*MaxSquaresPerRow + 63) / 64
+)
+
+// Bitboard is a set of squares on a board of up to 19x19, numbered row by
+// row from the top left corner.
+type Bitboard [bitboardWords]uint64
+
+func SquareBit(i int) Bitboard {
+	var b Bitboard
+	b.Set(i)
+
+	return b
+}
+
+func (b *Bitboard) Set(i int) {
+	b[uint(i)/64] |= 1 << (uint(i) % 64)
+}
+
+func (b *Bitboard) Clear(i int) {
+	b[uint(i)/64] &^= 1 << (uint(i) % 64)
+}
+
+func (b Bitboard) Has(i int) bool {
+	return b[uint(i)/64]&(1<<(uint(i)%64)) != 0
+}
+
+func (b Bitboard) IsEmpty() bool {
+	for _, w := range b {
+		if w != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (b Bitboard) Count() int {
+	n := 0
+
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+
+	return n
+}
+
+func (b Bitboard) And(o Bitboard) Bitboard {
+	for i := range b {
+		b[i] &= o[i]
+	}
+
+	return b
+}
+
+func (b Bitboard) Or(o Bitboard) Bitboard {
+	for i := range b {
+		b[i] |= o[i]
+	}
+
+	return b
+}
+
+func (b Bitboard) AndNot(o Bitboard) Bitboard {
+	for i := range b {
+		b[i] &^= o[i]
+	}
+
+	return b
+}
+
+// PopLowest removes the lowest numbered square from b and returns it, or
+// -1 when b is empty.
+func (b *Bitboard) PopLowest() int {
+	for i, w := range b {
+		if w != 0 {
+			b[i] &= w - 1
+			return i*64 + bits.TrailingZeros64(w)
+		}
+	}
+
+	return -1
+}
+
+// shiftUp and shiftDown move every square n places, where 0 < n < 64.
+func (b Bitboard) shiftUp(n uint) Bitboard {
+	var r Bitboard
+	r[0] = b[0] << n
+
+	for i := 1; i < len(b); i++ {
+		r[i] = b[i]<<n | b[i-1]>>(64-n)
+	}
+
+	return r
+}
+
+func (b Bitboard) shiftDown(n uint) Bitboard {
+	var r Bitboard
+	last := len(b) - 1
+
+	for i := 0; i < last; i++ {
+		r[i] = b[i]>>n | b[i+1]<<(64-n)
+	}
+
+	r[last] = b[last] >> n
+
+	return r
+}
+
+type Direction uint8
+
+const (
+	West Direction = iota
+	East
+	North
+	South
+)
+
+var Directions = [...]Direction{West, East, North, South}
+
+func (d Direction) Opposite() Direction {
+	return d ^ 1
+}
+
+// Geometry holds the masks needed to move bitboards around a board of a
+// given size without pieces wrapping from one edge to the other.
+type Geometry struct {
+	SquaresPerRow int
+
+	All         Bitboard
+	Edges       Bitboard
+	notWestFile Bitboard
+	notEastFile Bitboard
+
+	// words is how many words of a bitboard the board takes up.
+	words int
+
+	// rays holds, for every square and direction, the squares beyond it
+	// up to the edge of the board.
+	rays [MaxSquaresPerRow * MaxSquaresPerRow][len(Directions)]Bitboard
+}
+
+var geometries [MaxSquaresPerRow + 1]Geometry
+
+func init() {
+	for size := 1; size <= MaxSquaresPerRow; size++ {
+		g := &geometries[size]
+		g.SquaresPerRow = size
+		g.words = (size*size + 63) / 64
+
+		for row := 0; row < size; row++ {
+			for col := 0; col < size; col++ {
+				i := row*size + col
+				g.All.Set(i)
+
+				if col != 0 {
+					g.notWestFile.Set(i)
+				}
+
+				if col != size-1 {
+					g.notEastFile.Set(i)
+				}
+
+				if row == 0 || col == 0 || row == size-1 || col == size-1 {
+					g.Edges.Set(i)
+				}
+			}
+		}
+
+		for i := 0; i < size*size; i++ {
+			for _, d := range Directions {
+				for x := g.Shift(SquareBit(i), d); !x.IsEmpty(); x = g.Shift(x, d) {
+					g.rays[i][d] = g.rays[i][d].Or(x)
+				}
+			}
+		}
+	}
+}
+
+func GeometryFor(squaresPerRow int) *Geometry {
+	return &geometries[squaresPerRow]
+}
+
+func (g *Geometry) Index(row int, col int) int {
+	return row*g.SquaresPerRow + col
+}
+
+func (g *Geometry) RowCol(i int) (int, int) {
+	return i / g.SquaresPerRow, i % g.SquaresPerRow
+}
+
+// Shift moves every square in b one step in direction d, dropping the
+// squares that would leave the board.
+func (g *Geometry) Shift(b Bitboard, d Direction) Bitboard {
+	switch d {
+	case West:
+		return b.shiftDown(1).And(g.notEastFile)
+	case East:
+		return b.shiftUp(1).And(g.notWestFile)
+	case North:
+		return b.shiftDown(uint(g.SquaresPerRow))
+	default:
+		return b.shiftUp(uint(g.SquaresPerRow)).And(g.All)
+	}
+}
+
+// Slides returns every square a piece on square i reaches in a single move
+// through the squares in open: each ray from i is cut short at the nearest
+// square that is not open.
+func (g *Geometry) Slides(i int, open Bitboard) Bitboard {
+	var reached Bitboard
+
+	for _, d := range Directions {
+		ray := &g.rays[i][d]
+		nearest := -1
+
+		// A ray only runs through the words from i's own towards the end
+		// it heads for.
+		lo, hi := int(uint(i)/64), g.words-1
+
+		if d == West || d == North {
+			lo, hi = 0, lo
+
+			for w := hi; w >= 0 && nearest < 0; w-- {
+				if blocked := ray[w] &^ open[w]; blocked != 0 {
+					nearest = w*64 + 63 - bits.LeadingZeros64(blocked)
+				}
+			}
+		} else {
+			for w := lo; w <= hi && nearest < 0; w++ {
+				if blocked := ray[w] &^ open[w]; blocked != 0 {
+					nearest = w*64 + bits.TrailingZeros64(blocked)
+				}
+			}
+		}
+
+		if nearest < 0 {
+			for w := lo; w <= hi; w++ {
+				reached[w] |= ray[w]
+			}
+
+			continue
+		}
+
+		// The ray stops short of nearest, which is never open and so never
+		// reached along another ray.
+		beyond := &g.rays[nearest][d]
+		for w := lo; w <= hi; w++ {
+			reached[w] |= ray[w] &^ beyond[w]
+		}
+
+		reached.Clear(nearest)
+	}
+
+	return reached
+}
+
+// Reach returns every square any piece in from reaches in a single move
+// through the squares in open, moving the whole set a step at a time.
+func (g *Geometry) Reach(from Bitboard, open Bitboard) Bitboard {
+	var reached Bitboard
+
+	for _, d := range Directions {
+		for x := g.Shift(from, d).And(open); !x.IsEmpty(); x = g.Shift(x, d).And(open) {
+			reached = reached.Or(x)
+		}
+	}
+
+	return reached
+}
+
+// CaptureMask returns the victims caught between the piece on square i and
+// a hostile square directly behind them.
+func (g *Geometry) CaptureMask(i int, victims Bitboard, hostile Bitboard) Bitboard {
+	var captured Bitboard
+	from := SquareBit(i)
+
+	for _, d := range Directions {
+		victim := g.Shift(from, d).And(victims)
+		partner := g.Shift(victim, d).And(hostile)
+		captured = captured.Or(g.Shift(partner, d.Opposite()))
+	}
+
+	return captured
+}
@@ -0,0 +1,292 @@
+package board
+
+import (
+	"testing"
+
+	piece "github.com/technologyfreak/hnefatafl/piece"
+	square "github.com/technologyfreak/hnefatafl/square"
+)
+
+var copenhagen = []string{
+	"...bbbbb...",
+	".....b.....",
+	"...........",
+	"b....w....b",
+	"b...www...b",
+	"bb.wwkww.bb",
+	"b...www...b",
+	"b....w....b",
+	"...........",
+	".....b.....",
+	"...bbbbb...",
+}
+
+// endgame leaves the pieces room to slide, where walking a square at a
+// time costs the most.
+var endgame = []string{
+	"...b.......",
+	"...........",
+	"......b....",
+	"b..........",
+	"...........",
+	"..w..k.....",
+	"...........",
+	".........b.",
+	"....w......",
+	"...........",
+	".......b...",
+}
+
+func boardFromSetup(setup []string) Board {
+	b := NewBoard(int32(len(setup)))
+
+	for row, line := range setup {
+		for col, r := range line {
+			switch r {
+			case 'b':
+				b.Squares[col][row].AddPiece(piece.BlackPawn)
+			case 'w':
+				b.Squares[col][row].AddPiece(piece.WhitePawn)
+			case 'k':
+				b.Squares[col][row].AddPiece(piece.King | piece.WhitePawn)
+			}
+		}
+	}
+
+	return b
+}
+
+func layoutFromSetup(setup []string) Layout {
+	l := Layout{SquaresPerRow: len(setup)}
+
+	for row, line := range setup {
+		for col, r := range line {
+			switch r {
+			case 'b':
+				l.Put(row*l.SquaresPerRow+col, piece.BlackPawn)
+			case 'w':
+				l.Put(row*l.SquaresPerRow+col, piece.WhitePawn)
+			case 'k':
+				l.Put(row*l.SquaresPerRow+col, piece.King|piece.WhitePawn)
+			}
+		}
+	}
+
+	return l
+}
+
+// arrayWalk finds every empty square each piece can slide to by stepping
+// across the squares one at a time, the way the GUI used to.
+func arrayWalk(b *Board) int {
+	n := 0
+	steps := [...][2]int32{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+	for x := int32(0); x < b.SquaresPerRow; x++ {
+		for y := int32(0); y < b.SquaresPerRow; y++ {
+			if !b.Squares[x][y].HasPiece() {
+				continue
+			}
+
+			for _, step := range steps {
+				s := &b.Squares[x][y]
+
+				for {
+					col := square.ToRowOrCol(s.X + step[0]*square.SquareSize)
+					row := square.ToRowOrCol(s.Y + step[1]*square.SquareSize)
+
+					if !b.InRowRange(col) || !b.InRowRange(row) || b.Squares[col][row].HasPiece() {
+						break
+					}
+
+					s = &b.Squares[col][row]
+					n++
+				}
+			}
+		}
+	}
+
+	return n
+}
+
+func bitboardWalk(l *Layout) int {
+	n := 0
+	g := l.Geometry()
+	open := g.All.AndNot(l.Occupied())
+
+	for from := l.Occupied(); !from.IsEmpty(); {
+		n += g.Slides(from.PopLowest(), open).Count()
+	}
+
+	return n
+}
+
+// arrayReach marks every empty square a black piece can slide to.
+func arrayReach(b *Board) int {
+	reached := make([][]bool, b.SquaresPerRow)
+	for x := range reached {
+		reached[x] = make([]bool, b.SquaresPerRow)
+	}
+
+	n := 0
+	steps := [...][2]int32{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+
+	for x := int32(0); x < b.SquaresPerRow; x++ {
+		for y := int32(0); y < b.SquaresPerRow; y++ {
+			if b.Squares[x][y].Piece != piece.BlackPawn {
+				continue
+			}
+
+			for _, step := range steps {
+				for col, row := x+step[0], y+step[1]; b.InRowRange(col) && b.InRowRange(row) && !b.Squares[col][row].HasPiece(); col, row = col+step[0], row+step[1] {
+					if !reached[col][row] {
+						reached[col][row] = true
+						n++
+					}
+				}
+			}
+		}
+	}
+
+	return n
+}
+
+func bitboardReach(l *Layout) int {
+	g := l.Geometry()
+
+	return g.Reach(l.Black, g.All.AndNot(l.Occupied())).Count()
+}
+
+func TestLayoutRoundTrip(t *testing.T) {
+	b := boardFromSetup(copenhagen)
+	l := b.Layout()
+
+	if l.Black.Count() != 24 || l.White.Count() != 13 || l.King.Count() != 1 {
+		t.Fatalf("got %d black, %d white, %d king", l.Black.Count(), l.White.Count(), l.King.Count())
+	}
+
+	if l != layoutFromSetup(copenhagen) {
+		t.Fatal("the board's layout differs from the setup's")
+	}
+
+	back := NewBoard(int32(l.SquaresPerRow))
+	back.SetLayout(&l)
+
+	for x := range b.Squares {
+		for y := range b.Squares[x] {
+			if back.Squares[x][y] != b.Squares[x][y] {
+				t.Fatalf("square %d,%d: got %v, want %v", x, y, back.Squares[x][y], b.Squares[x][y])
+			}
+		}
+	}
+}
+
+func TestShiftDoesNotWrap(t *testing.T) {
+	for size := 1; size <= MaxSquaresPerRow; size++ {
+		g := GeometryFor(size)
+		last := size - 1
+
+		if !g.Shift(SquareBit(g.Index(0, 0)), West).IsEmpty() ||
+			!g.Shift(SquareBit(g.Index(0, last)), East).IsEmpty() ||
+			!g.Shift(SquareBit(g.Index(0, 0)), North).IsEmpty() ||
+			!g.Shift(SquareBit(g.Index(last, last)), South).IsEmpty() {
+			t.Fatalf("size %d: shift wrapped around the board", size)
+		}
+
+		if got := g.Shift(g.All, East).Count(); got != size*(size-1) {
+			t.Fatalf("size %d: shifting east kept %d squares", size, got)
+		}
+	}
+}
+
+func TestSlidesMatchArrayWalk(t *testing.T) {
+	for _, setup := range [][]string{copenhagen, endgame} {
+		b := boardFromSetup(setup)
+		l := layoutFromSetup(setup)
+
+		if got, want := bitboardWalk(&l), arrayWalk(&b); got != want {
+			t.Fatalf("bitboard found %d moves, array walk %d", got, want)
+		}
+	}
+}
+
+func TestReachMatchesArrayWalk(t *testing.T) {
+	b := boardFromSetup(copenhagen)
+	l := layoutFromSetup(copenhagen)
+
+	if got, want := bitboardReach(&l), arrayReach(&b); got != want {
+		t.Fatalf("bitboard reached %d squares, array walk %d", got, want)
+	}
+}
+
+func TestCaptureMask(t *testing.T) {
+	l := layoutFromSetup([]string{
+		".....",
+		".bwb.",
+		"..wb.",
+		"..b..",
+		".....",
+	})
+	g := l.Geometry()
+
+	got := g.CaptureMask(g.Index(1, 1), l.White, l.Black)
+	want := SquareBit(g.Index(1, 2))
+
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got = g.CaptureMask(g.Index(3, 2), l.White, l.Black)
+	if !got.IsEmpty() {
+		t.Fatalf("got %v, want nothing", got)
+	}
+}
+
+// In the crowded opening every ray is short, and the rays come out a little
+// slower than walking the array; they pay off once the board opens up.
+func BenchmarkArrayWalk(b *testing.B) {
+	board := boardFromSetup(copenhagen)
+
+	for i := 0; i < b.N; i++ {
+		arrayWalk(&board)
+	}
+}
+
+func BenchmarkBitboardWalk(b *testing.B) {
+	l := layoutFromSetup(copenhagen)
+
+	for i := 0; i < b.N; i++ {
+		bitboardWalk(&l)
+	}
+}
+
+func BenchmarkArrayWalkEndgame(b *testing.B) {
+	board := boardFromSetup(endgame)
+
+	for i := 0; i < b.N; i++ {
+		arrayWalk(&board)
+	}
+}
+
+func BenchmarkBitboardWalkEndgame(b *testing.B) {
+	l := layoutFromSetup(endgame)
+
+	for i := 0; i < b.N; i++ {
+		bitboardWalk(&l)
+	}
+}
+
+func BenchmarkArrayReach(b *testing.B) {
+	board := boardFromSetup(copenhagen)
+
+	for i := 0; i < b.N; i++ {
+		arrayReach(&board)
+	}
+}
+
+func BenchmarkBitboardReach(b *testing.B) {
+	l := layoutFromSetup(copenhagen)
+
+	for i := 0; i < b.N; i++ {
+		bitboardReach(&l)
+	}
+}
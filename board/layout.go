@@ -0,0 +1,85 @@
+package board
+
+import (
+	piece "github.com/technologyfreak/hnefatafl/piece"
+)
+
+// Layout is the bitboard form of a board: one set of squares per kind of
+// piece. White includes the king.
+type Layout struct {
+	SquaresPerRow int
+
+	Black Bitboard
+	White Bitboard
+	King  Bitboard
+
+	hash uint64
+}
+
+func (l *Layout) Geometry() *Geometry {
+	return GeometryFor(l.SquaresPerRow)
+}
+
+func (l *Layout) Occupied() Bitboard {
+	return l.Black.Or(l.White)
+}
+
+func (l *Layout) At(i int) piece.PieceKind {
+	switch {
+	case l.Black.Has(i):
+		return piece.BlackPawn
+	case l.King.Has(i):
+		return piece.King | piece.WhitePawn
+	case l.White.Has(i):
+		return piece.WhitePawn
+	}
+
+	return piece.None
+}
+
+// Hash is the Zobrist hash of the piece placement. Put keeps it up to date
+// so moves and captures only touch the squares they change.
+func (l *Layout) Hash() uint64 {
+	return l.hash
+}
+
+func (l *Layout) Put(i int, kind piece.PieceKind) {
+	l.hash ^= ZobristKey(i, l.At(i)) ^ ZobristKey(i, kind)
+
+	l.Black.Clear(i)
+	l.White.Clear(i)
+	l.King.Clear(i)
+
+	switch {
+	case kind&piece.BlackPawn == piece.BlackPawn:
+		l.Black.Set(i)
+	case kind&piece.King == piece.King:
+		l.King.Set(i)
+		l.White.Set(i)
+	case kind&piece.WhitePawn == piece.WhitePawn:
+		l.White.Set(i)
+	}
+}
+
+// SetLayout puts the pieces of l on the board's squares.
+func (b *Board) SetLayout(l *Layout) {
+	for row := 0; row < l.SquaresPerRow; row++ {
+		for col := 0; col < l.SquaresPerRow; col++ {
+			b.Squares[col][row].AddPiece(l.At(row*l.SquaresPerRow + col))
+		}
+	}
+}
+
+// Layout reads the pieces on the board's squares into bitboards; SetLayout
+// is the other way round.
+func (b *Board) Layout() Layout {
+	l := Layout{SquaresPerRow: int(b.SquaresPerRow)}
+
+	for row := 0; row < l.SquaresPerRow; row++ {
+		for col := 0; col < l.SquaresPerRow; col++ {
+			l.Put(row*l.SquaresPerRow+col, b.Squares[col][row].Piece)
+		}
+	}
+
+	return l
+}
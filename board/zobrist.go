@@ -0,0 +1,62 @@
+package board
+
+import (
+	piece "github.com/technologyfreak/hnefatafl/piece"
+)
+
+// zobristSeed fixes the keys so hashes stay the same from run to run and
+// saved opening books remain valid. Never change it.
+const zobristSeed = 0x9e3779b97f4a7c15
+
+const (
+	zobristBlack = iota
+	zobristWhite
+	zobristKing
+	zobristKinds
+)
+
+var (
+	zobristPieces [zobristKinds][MaxSquaresPerRow * MaxSquaresPerRow]uint64
+
+	// ZobristBlackToMove is mixed into a position's hash when it is
+	// black's turn.
+	ZobristBlackToMove uint64
+)
+
+// splitmix64 is used rather than math/rand, whose sequence is not
+// guaranteed to stay the same between Go releases.
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
+
+func init() {
+	state := uint64(zobristSeed)
+
+	for kind := range zobristPieces {
+		for i := range zobristPieces[kind] {
+			zobristPieces[kind][i] = splitmix64(&state)
+		}
+	}
+
+	ZobristBlackToMove = splitmix64(&state)
+}
+
+// ZobristKey is the key of a piece of the given kind standing on square i,
+// or 0 for an empty square.
+func ZobristKey(i int, kind piece.PieceKind) uint64 {
+	switch {
+	case kind&piece.BlackPawn == piece.BlackPawn:
+		return zobristPieces[zobristBlack][i]
+	case kind&piece.King == piece.King:
+		return zobristPieces[zobristKing][i]
+	case kind&piece.WhitePawn == piece.WhitePawn:
+		return zobristPieces[zobristWhite][i]
+	}
+
+	return 0
+}
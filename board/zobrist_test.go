@@ -0,0 +1,46 @@
+package board
+
+import (
+	"testing"
+
+	piece "github.com/technologyfreak/hnefatafl/piece"
+)
+
+// Saved opening books depend on these values staying put.
+func TestZobristIsStable(t *testing.T) {
+	l := layoutFromSetup(copenhagen)
+
+	if got, want := l.Hash(), uint64(0x767dd2da2318d3ad); got != want {
+		t.Fatalf("got %#x, want %#x", got, want)
+	}
+
+	if got, want := ZobristBlackToMove, uint64(0x203a49718d3b7b63); got != want {
+		t.Fatalf("got %#x, want %#x", got, want)
+	}
+}
+
+func TestZobristIsIncremental(t *testing.T) {
+	l := layoutFromSetup(copenhagen)
+	g := l.Geometry()
+
+	// Move a pawn next to a defender and take it.
+	l.Put(g.Index(0, 3), piece.None)
+	l.Put(g.Index(2, 3), piece.BlackPawn)
+	l.Put(g.Index(3, 4), piece.None)
+	l.Put(g.Index(3, 5), piece.None)
+	l.Put(g.Index(3, 4), piece.WhitePawn)
+
+	want := Layout{SquaresPerRow: l.SquaresPerRow}
+	for i := 0; i < l.SquaresPerRow*l.SquaresPerRow; i++ {
+		want.Put(i, l.At(i))
+	}
+
+	if l.Hash() != want.Hash() {
+		t.Fatalf("incremental hash %#x, recomputed %#x", l.Hash(), want.Hash())
+	}
+
+	before := layoutFromSetup(copenhagen)
+	if l.Hash() == before.Hash() {
+		t.Fatal("hash did not change")
+	}
+}
@@ -0,0 +1,116 @@
+// Command arena plays engines against each other without a window and
+// reports how they fared, for tuning the AI.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	arena "github.com/technologyfreak/hnefatafl/arena"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func main() {
+	specA := flag.String("a", "alphabeta,level=medium", "first engine, such as alphabeta,depth=3,king-ring=20 or mcts,playouts=500")
+	specB := flag.String("b", "alphabeta,level=medium,style=aggressive", "second engine")
+	games := flag.Int("games", 20, "games to play, taking turns at each side")
+	variants := flag.String("variants", rules.Brandubh.ID, "comma-separated variants to play in turn")
+	randomPlies := flag.Int("random-plies", 2, "random moves to open each pair of games with")
+	maxMoves := flag.Int("max-moves", 500, "draw games that go on this long, on variants without a move limit")
+	seed := flag.Int64("seed", 1, "seed for the random openings")
+	out := flag.String("out", "arena-results", "directory for the results file and game records")
+	flag.Parse()
+
+	match := &arena.Match{
+		Games:       *games,
+		RandomPlies: *randomPlies,
+		MaxMoves:    *maxMoves,
+		Seed:        *seed,
+	}
+
+	for _, id := range strings.Split(*variants, ",") {
+		v, ok := rules.VariantByID(strings.TrimSpace(id))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown variant %q\n", id)
+			os.Exit(2)
+		}
+
+		match.Variants = append(match.Variants, v)
+	}
+
+	var err error
+
+	if match.A, err = arena.ParseEntrant(*specA); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer match.A.Close()
+
+	if match.B, err = arena.ParseEntrant(*specB); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defer match.B.Close()
+
+	if err := run(match, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run plays the match, writing each game to a file of its own and a line
+// for it to results.txt as it ends, then the score.
+func run(match *arena.Match, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	results, err := os.Create(filepath.Join(dir, "results.txt"))
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+
+	fmt.Fprintf(results, "A: %v\nB: %v\n\n", match.A.Name, match.B.Name)
+
+	var writeErr error
+
+	match.Played = func(g arena.Game, s arena.Score) {
+		name := fmt.Sprintf("game-%03d-%v.txt", g.Number, g.Variant.ID)
+		if err := writeMoves(filepath.Join(dir, name), g); err != nil && writeErr == nil {
+			writeErr = err
+		}
+
+		line := fmt.Sprintf("%3d %-16v black: %v, white: %v, %v in %v moves, %v",
+			g.Number, g.Variant.ID, g.Black, g.White, g.Outcome, len(g.Moves), name)
+		fmt.Fprintln(results, line)
+		fmt.Printf("%v\n    A %v\n", line, s)
+	}
+
+	score, err := match.Run(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(results, "\nA against B: %v\n", score)
+	fmt.Printf("\nA against B: %v\n", score)
+
+	return writeErr
+}
+
+// writeMoves saves the moves of a game one per line, as the game's -replay
+// flag reads them.
+func writeMoves(path string, g arena.Game) error {
+	var b strings.Builder
+
+	for _, m := range g.Moves {
+		b.WriteString(rules.FormatMove(m, g.Variant.Size))
+		b.WriteByte('\n')
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
@@ -0,0 +1,53 @@
+// Command hnefatafl-engine plays as an OpenTafl Engine Protocol engine over
+// stdin and stdout, so the AI can play inside OpenTafl and other hosts.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	otep "github.com/technologyfreak/hnefatafl/otep"
+)
+
+func main() {
+	engine := flag.String("engine", "alphabeta", "how to pick moves: alphabeta or mcts")
+	level := flag.String("level", "hard", "how well to play: beginner, easy, medium or hard")
+	style := flag.String("style", "balanced", "how to play: balanced, aggressive or runner")
+	workers := flag.Int("workers", runtime.NumCPU(), "goroutines the alphabeta engine searches with")
+	flag.Parse()
+
+	l, err := ai.ParseLevel(*level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	s, err := ai.ParseStyle(*style)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var e ai.Engine
+
+	switch *engine {
+	case "alphabeta":
+		searcher := l.Searcher(s)
+		searcher.Workers = *workers
+		e = searcher
+	case "mcts":
+		e = l.MCTS()
+	default:
+		fmt.Fprintf(os.Stderr, "unknown engine %q\n", *engine)
+		os.Exit(2)
+	}
+
+	if err := otep.Serve(context.Background(), os.Stdin, os.Stdout, e); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
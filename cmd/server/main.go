@@ -0,0 +1,57 @@
+// Command server hosts games between players on different machines. It
+// keeps a lobby of open rooms, private rooms joined by invite code and a
+// quick-match queue, and checks every move. Desktop
+// clients connect over TCP; browsers load a client over HTTP and play
+// through a websocket.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	netplay "github.com/technologyfreak/hnefatafl/netplay"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+	web "github.com/technologyfreak/hnefatafl/web"
+)
+
+func main() {
+	addr := flag.String("addr", ":7373", "address to listen on for desktop clients")
+	httpAddr := flag.String("http", ":8080", "address to serve the browser client on, empty for none")
+	variantID := flag.String("variant", rules.Copenhagen.ID, "variant to play when a player does not ask for one")
+	grace := flag.Duration("grace", netplay.DefaultGrace, "how long a player who drops out of a game has to come back before losing it")
+	flag.Parse()
+
+	variant, ok := rules.VariantByID(*variantID)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown variant %q\n", *variantID)
+		os.Exit(2)
+	}
+
+	if *grace <= 0 {
+		fmt.Fprintln(os.Stderr, "-grace must be positive")
+		os.Exit(2)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("serving %v on %v", variant.Name, l.Addr())
+
+	s := &netplay.Server{Variant: variant, Grace: *grace}
+
+	if *httpAddr != "" {
+		log.Printf("serving the browser client on %v", *httpAddr)
+
+		go func() {
+			log.Fatal(http.ListenAndServe(*httpAddr, web.Handler(s)))
+		}()
+	}
+
+	log.Fatal(s.Serve(l))
+}
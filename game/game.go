@@ -1,31 +1,25 @@
 package game
 
 import (
+	"context"
+
 	raylib "github.com/gen2brain/raylib-go/raylib"
 	board "github.com/technologyfreak/hnefatafl/board"
 	piece "github.com/technologyfreak/hnefatafl/piece"
+	player "github.com/technologyfreak/hnefatafl/player"
 	resources "github.com/technologyfreak/hnefatafl/resources"
+	rules "github.com/technologyfreak/hnefatafl/rules"
 	square "github.com/technologyfreak/hnefatafl/square"
 )
 
 const (
-	screenWidth     = square.SquareSize * square.SquaresPerRow
-	screenHeight    = screenWidth + square.SquareSize
-	totalBlackPawns = 24
-	totalWhitePawns = 12
-	fontSize        = 25
-	targetFPS       = 60
-	leftPadding     = 10
-	rightPadding    = 20
-)
-
-type NeigborKind uint8
-
-const (
-	Unopposed NeigborKind = iota
-	Edge
-	KingsSquare
-	Opposed
+	minSquaresPerRow        = 11
+	backgroundSquaresPerRow = 11
+	fontSize                = 25
+	reasonFontSize          = 20
+	targetFPS               = 60
+	leftPadding             = 10
+	rightPadding            = 20
 )
 
 const (
@@ -33,14 +27,10 @@ const (
 	WhitesTurnMsg   = "Whites's Turn"
 	BlackWinsMsg    = "Black Wins!"
 	WhiteWinsMsg    = "White Wins!"
+	DrawMsg         = "Draw!"
 	RestartBtnValue = "Click Here To Restart"
 )
 
-type CoordPair struct {
-	X int32
-	Y int32
-}
-
 func padLeft(x int32) int32 {
 	return x - leftPadding
 }
@@ -60,18 +50,39 @@ type Game struct {
 	RestartBtnX     int32
 	RestartBtnY     int32
 
-	BlackPawns uint8
-	WhitePawns uint8
-	MovePhase  uint8
+	Outcome rules.Outcome
+
+	Variant *rules.Variant
 
-	BlacksTurn              bool
-	ShouldHighlightSelected bool
-	Win                     bool
+	// AdjustRules, when set, changes the rules of every new game.
+	AdjustRules func(*rules.RuleSet)
 
+	Menu   Menu
+	InMenu bool
+
+	rules.Position
 	board.Board
 
-	Selected     *square.Square
-	PrevSelected *square.Square
+	Selected      *square.Square
+	SelectedMoves []rules.Move
+
+	// Players is indexed by rules.Side. Sides given a player before Init
+	// keep it; the menu picks the others.
+	Players   [2]player.Player
+	Mouse     *Mouse
+	PlayerErr error
+
+	// Online, when set before Init, plays on a server instead of the menu.
+	Online *Online
+
+	// Notice, when set, is shown in place of whose turn it is, such as
+	// while waiting for an opponent.
+	Notice string
+
+	preset [2]player.Player
+
+	pending    chan playerMove
+	cancelMove context.CancelFunc
 
 	BoardBackground raylib.Texture2D
 	BlackPawnSprite raylib.Texture2D
@@ -79,24 +90,32 @@ type Game struct {
 	KingSprite      raylib.Texture2D
 }
 
+type playerMove struct {
+	move rules.Move
+	err  error
+}
+
 func (g *Game) Init() {
-	g.ScreenWidth = screenWidth
-	g.ScreenHeight = screenHeight
-	g.BoardHeight = screenWidth
+	if g.Variant == nil {
+		g.Variant = rules.Copenhagen
+	}
 
-	g.Restart()
+	if g.Menu.Variant == nil {
+		g.Menu.Variant = g.Variant
+	}
 
-	raylib.InitWindow(int32(g.ScreenWidth), int32(g.ScreenHeight), "Hnefatafl")
-	defer raylib.CloseWindow()
+	if g.Menu.Engine == "" {
+		g.Menu.Engine = Engines[0]
+	}
 
-	g.TurnMsgX = g.ScreenWidth/2 - raylib.MeasureText("XXXXX's Turn", fontSize)/2
-	g.MsgY = g.ScreenHeight - fontSize
+	g.Mouse = NewMouse()
+	g.preset = g.Players
 
-	g.WinMsgX = g.ScreenWidth/2 - raylib.MeasureText("XXXXX Wins!", fontSize)/2
+	width, height := g.screenSize()
+	raylib.InitWindow(width, height, "Hnefatafl")
+	defer raylib.CloseWindow()
 
-	g.RestartBtnWidth = raylib.MeasureText(RestartBtnValue, fontSize)
-	g.RestartBtnX = g.ScreenWidth/2 - g.RestartBtnWidth/2
-	g.RestartBtnY = g.ScreenHeight/2 - g.RestartBtnWidth/2
+	g.Resize()
 
 	img := raylib.LoadImageFromMemory(".png", resources.BoardBackground, int32(len(resources.BoardBackground)))
 	g.BoardBackground = raylib.LoadTextureFromImage(img)
@@ -120,431 +139,229 @@ func (g *Game) Init() {
 
 	raylib.SetTargetFPS(targetFPS)
 
-	for !raylib.WindowShouldClose() {
-		g.Update()
-		g.Draw()
-	}
-
-}
-
-func (g *Game) SelectSquare() {
-	if g.MovePhase == 0 {
-		g.Selected = nil
-		g.PrevSelected = nil
+	if g.Online != nil {
+		g.Restart()
 	} else {
-		g.ShouldHighlightSelected = false
-		g.PrevSelected = g.Selected
+		g.ShowMenu()
 	}
 
-	row := raylib.GetMouseX()
-	col := raylib.GetMouseY()
-
-	row = square.ToRowOrCol(row)
-	col = square.ToRowOrCol(col)
+	for !raylib.WindowShouldClose() {
+		if g.InMenu {
+			g.UpdateMenu()
+			g.DrawMenu()
+			continue
+		}
 
-	if square.InRowRange(row) && square.InRowRange(col) {
-		g.Selected = &g.Board.Squares[row][col]
-		g.MovePhase++
+		g.Update()
+		g.Draw()
 	}
-}
 
-func (g *Game) TryMoveWest(wPiece *square.Square, blank *square.Square) int32 {
-	if blank.IsWestOf(wPiece) &&
-		wPiece.Y == blank.Y {
+	g.StopWaiting()
 
-		x := wPiece.X - square.SquareSize
-		col := wPiece.Y
+}
 
-		col = square.ToRowOrCol(col)
+func (g *Game) screenSize() (int32, int32) {
+	boardHeight := int32(g.Variant.Size) * square.SquareSize
 
-		if !square.InRowRange(col) {
-			return -1
-		}
+	return int32(max(g.Variant.Size, minSquaresPerRow)) * square.SquareSize, boardHeight + square.SquareSize
+}
 
-		for x > blank.X && x > 0 {
-			selected := &g.Board.Squares[square.ToRowOrCol(x)][col]
+// Resize fits the window and everything placed on it to the variant.
+func (g *Game) Resize() {
+	g.ScreenWidth, g.ScreenHeight = g.screenSize()
+	g.BoardHeight = int32(g.Variant.Size) * square.SquareSize
 
-			if selected.HasPiece() {
-				return -1
-			}
+	raylib.SetWindowSize(int(g.ScreenWidth), int(g.ScreenHeight))
+	raylib.SetWindowTitle("Hnefatafl - " + g.Variant.Name)
 
-			x -= square.SquareSize
-		}
+	g.TurnMsgX = g.ScreenWidth/2 - raylib.MeasureText("XXXXX's Turn", fontSize)/2
+	g.MsgY = g.ScreenHeight - fontSize
 
-		g.MovePhase++
-		return 0
-	}
+	g.WinMsgX = g.ScreenWidth/2 - raylib.MeasureText("XXXXX Wins!", fontSize)/2
 
-	return -1
+	g.RestartBtnWidth = raylib.MeasureText(RestartBtnValue, fontSize)
+	g.RestartBtnX = g.ScreenWidth/2 - g.RestartBtnWidth/2
+	g.RestartBtnY = g.ScreenHeight/2 - g.RestartBtnWidth/2
 }
 
-func (g *Game) TryMoveEast(wPiece *square.Square, blank *square.Square) int32 {
-	if blank.IsEastOf(wPiece) &&
-		wPiece.Y == blank.Y {
-
-		x := wPiece.X + square.SquareSize
-		col := wPiece.Y
-
-		col = square.ToRowOrCol(col)
+// NewGame starts the game chosen on the menu.
+func (g *Game) NewGame() {
+	g.Variant = g.Menu.Variant
+	g.Players = g.Menu.Players()
 
-		if !square.InRowRange(col) {
-			return -1
+	for side, p := range g.preset {
+		if p != nil {
+			g.Players[side] = p
 		}
+	}
 
-		for x < blank.X && square.ToRowOrCol(x) < square.SquaresPerRow {
-			selected := &g.Board.Squares[square.ToRowOrCol(x)][col]
-
-			if selected.HasPiece() {
-				return -1
-			}
-
-			x += square.SquareSize
+	for side, p := range g.Players {
+		if p == nil {
+			g.Players[side] = g.Mouse
 		}
-
-		g.MovePhase++
-		return 0
 	}
 
-	return -1
+	g.Resize()
+	g.Restart()
+	g.InMenu = false
 }
 
-func (g *Game) TryMoveNorth(wPiece *square.Square, blank *square.Square) int32 {
-	if blank.IsNorthOf(wPiece) &&
-		wPiece.X == blank.X {
-
-		y := wPiece.Y - square.SquareSize
-		row := wPiece.X
-
-		row = square.ToRowOrCol(row)
-
-		if !square.InRowRange(row) {
-			return -1
-		}
-
-		for y > blank.Y && y > 0 {
-			selected := &g.Board.Squares[row][square.ToRowOrCol(y)]
-
-			if selected.HasPiece() {
-				return -1
-			}
-
-			y -= square.SquareSize
-		}
+func (g *Game) SquareUnderMouse() *square.Square {
+	row := square.ToRowOrCol(raylib.GetMouseX())
+	col := square.ToRowOrCol(raylib.GetMouseY())
 
-		g.MovePhase++
-		return 0
+	if g.Board.InRowRange(row) && g.Board.InRowRange(col) {
+		return &g.Board.Squares[row][col]
 	}
 
-	return -1
+	return nil
 }
 
-func (g *Game) TryMoveSouth(wPiece *square.Square, blank *square.Square) int32 {
-	if blank.IsSouthOf(wPiece) &&
-		wPiece.X == blank.X {
+func (g *Game) SelectSquare(s *square.Square) {
+	g.Selected = nil
+	g.SelectedMoves = nil
 
-		y := wPiece.Y + square.SquareSize
-		row := wPiece.X
-
-		row = square.ToRowOrCol(row)
-
-		if !square.InRowRange(row) {
-			return -1
-		}
-
-		for y < blank.Y && square.ToRowOrCol(y) < square.SquaresPerRow {
-			selected := &g.Board.Squares[row][square.ToRowOrCol(y)]
-
-			if selected.HasPiece() {
-				return -1
-			}
-
-			y += square.SquareSize
-		}
-
-		g.MovePhase++
-		return 0
+	if s == nil {
+		return
 	}
 
-	return -1
-}
+	moves := g.Position.MovesFrom(ToCoord(s))
 
-func (g *Game) ValidateMove() {
-	if g.MovePhase == 1 {
-		if g.Selected.HasPiece() &&
-			((g.Selected.Piece&piece.BlackPawn == piece.BlackPawn && g.BlacksTurn) || (g.Selected.Piece&piece.WhitePawn == piece.WhitePawn && !g.BlacksTurn)) {
-			g.MovePhase++
-			g.ShouldHighlightSelected = true
-		} else {
-			g.MovePhase = 0
-		}
+	if len(moves) > 0 {
+		g.Selected = s
+		g.SelectedMoves = moves
 	}
+}
 
-	if g.MovePhase == 3 {
-		if !g.Selected.HasPiece() {
-			if (g.Selected.IsKingsCorner() || g.Selected.IsCenter()) &&
-				g.PrevSelected.Piece&piece.King != piece.King {
-				g.MovePhase = 0
-			}
+// HandleClick moves the selected piece when s is one of its legal
+// destinations and otherwise (re)selects s.
+func (g *Game) HandleClick(s *square.Square) {
+	if g.Selected != nil && s != nil {
+		move := rules.Move{From: ToCoord(g.Selected), To: ToCoord(s)}
 
-			g.MovePhase++
-		} else {
-			g.MovePhase = 0
+		if g.Position.IsLegal(move) {
+			g.Mouse.Play(move)
+			g.SelectSquare(nil)
+			return
 		}
 	}
 
-	if g.MovePhase == 4 {
-		if g.TryMoveWest(g.PrevSelected, g.Selected) == -1 &&
-			g.TryMoveEast(g.PrevSelected, g.Selected) == -1 &&
-			g.TryMoveNorth(g.PrevSelected, g.Selected) == -1 &&
-			g.TryMoveSouth(g.PrevSelected, g.Selected) == -1 {
-			g.MovePhase = 0
-		}
-	}
+	g.SelectSquare(s)
 }
 
-func (g *Game) KingHasReachedACorner() bool {
-	return (g.Board.Squares[0][0].Piece&piece.King == piece.King) ||
-		(g.Board.Squares[0][10].Piece&piece.King == piece.King) ||
-		(g.Board.Squares[10][0].Piece&piece.King == piece.King) ||
-		(g.Board.Squares[10][10].Piece&piece.King == piece.King)
-}
+func (g *Game) Restart() {
+	g.StopWaiting()
+	g.PlayerErr = nil
+	g.Notice = ""
 
-func (g *Game) GetWesternNeighbor(wPiece *square.Square) (NeigborKind, *square.Square) {
-	if wPiece == nil {
-		return Edge, nil
+	r := g.Variant.Rules
+	if g.AdjustRules != nil {
+		g.AdjustRules(&r)
 	}
 
-	west := CoordPair{X: square.ToRowOrCol(wPiece.X - square.SquareSize), Y: square.ToRowOrCol(wPiece.Y)}
-
-	if square.InRowRange(west.X) && square.InRowRange(west.Y) {
-		neigbor := &g.Board.Squares[west.X][west.Y]
-		if neigbor.HasPiece() {
-			if (neigbor.Piece & piece.BlackPawn) != (wPiece.Piece & piece.BlackPawn) {
-				return Opposed, neigbor
-			}
-		} else if neigbor.IsKingsCorner() {
-			return KingsSquare, neigbor
-		}
+	g.Position = rules.NewPositionWithRules(g.Variant, r)
 
-		return Unopposed, neigbor
-	}
+	g.Outcome = g.Position.Outcome()
 
-	return Edge, nil
+	g.Board = board.NewBoard(int32(g.Variant.Size))
+	g.SyncBoard()
+	g.SelectSquare(nil)
 }
 
-func (g *Game) GetEasternNeighbor(wPiece *square.Square) (NeigborKind, *square.Square) {
-	if wPiece == nil {
-		return Edge, nil
-	}
-
-	east := CoordPair{X: square.ToRowOrCol(wPiece.X + square.SquareSize), Y: square.ToRowOrCol(wPiece.Y)}
-
-	if square.InRowRange(east.X) && square.InRowRange(east.Y) {
-		neigbor := &g.Board.Squares[east.X][east.Y]
-		if neigbor.HasPiece() {
-			if (neigbor.Piece & piece.BlackPawn) != (wPiece.Piece & piece.BlackPawn) {
-				return Opposed, neigbor
-			}
-		} else if neigbor.IsKingsCorner() {
-			return KingsSquare, neigbor
-		}
-
-		return Unopposed, neigbor
-	}
-
-	return Edge, nil
+func (g *Game) IsMousesTurn() bool {
+	return g.Players[g.Position.ToMove()] == g.Mouse
 }
 
-func (g *Game) GetNorthernNeighbor(wPiece *square.Square) (NeigborKind, *square.Square) {
-	if wPiece == nil {
-		return Edge, nil
-	}
-
-	north := CoordPair{X: square.ToRowOrCol(wPiece.X), Y: square.ToRowOrCol(wPiece.Y - square.SquareSize)}
-
-	if square.InRowRange(north.X) && square.InRowRange(north.Y) {
-		neigbor := &g.Board.Squares[north.X][north.Y]
-		if neigbor.HasPiece() {
-			if (neigbor.Piece & piece.BlackPawn) != (wPiece.Piece & piece.BlackPawn) {
-				return Opposed, neigbor
-			}
-		} else if neigbor.IsKingsCorner() {
-			return KingsSquare, neigbor
+// UpdatePlayers asks the side to move for its move in the background and
+// plays it once it arrives. Nobody is asked while no one plays that side,
+// such as before an online game starts.
+func (g *Game) UpdatePlayers() {
+	if g.pending != nil {
+		select {
+		case pm := <-g.pending:
+			g.StopWaiting()
+			g.PlayMove(pm.move, pm.err)
+		default:
 		}
 
-		return Unopposed, neigbor
+		return
 	}
 
-	return Edge, nil
-}
-
-func (g *Game) GetSouthernNeighbor(wPiece *square.Square) (NeigborKind, *square.Square) {
-	if wPiece == nil {
-		return Edge, nil
+	pl := g.Players[g.Position.ToMove()]
+	if pl == nil || g.PlayerErr != nil || g.Position.Outcome().IsOver() {
+		return
 	}
 
-	south := CoordPair{X: square.ToRowOrCol(wPiece.X), Y: square.ToRowOrCol(wPiece.Y + square.SquareSize)}
-
-	if square.InRowRange(south.X) && square.InRowRange(south.Y) {
-		neigbor := &g.Board.Squares[south.X][south.Y]
-		if neigbor.HasPiece() {
-			if (neigbor.Piece & piece.BlackPawn) != (wPiece.Piece & piece.BlackPawn) {
-				return Opposed, neigbor
-			}
-		} else if neigbor.IsKingsCorner() {
-			return KingsSquare, neigbor
-		}
-
-		return Unopposed, neigbor
-	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pending := make(chan playerMove, 1)
+	g.pending, g.cancelMove = pending, cancel
 
-	return Edge, nil
+	go func(pl player.Player, p rules.Position) {
+		move, err := pl.NextMove(ctx, p)
+		pending <- playerMove{move: move, err: err}
+	}(pl, g.Position)
 }
 
-func (g *Game) IsSandwiched(wPiece *square.Square) bool {
-	if wPiece == nil {
-		return false
+// PlayMove applies a move from the side to move and tells its opponent.
+func (g *Game) PlayMove(move rules.Move, err error) {
+	if err == nil {
+		err = g.Position.Apply(move)
 	}
 
-	westKind, westPiece := g.GetWesternNeighbor(wPiece)
-	eastKind, eastPiece := g.GetEasternNeighbor(wPiece)
-	northKind, northPiece := g.GetNorthernNeighbor(wPiece)
-	southKind, southPiece := g.GetSouthernNeighbor(wPiece)
-
-	TryIsCenter := func(s *square.Square) bool {
-		return s != nil && s.IsCenter()
-	}
-
-	hasWest := westKind > 0
-	hasEast := eastKind > 0
-	hasNorth := northKind > 0
-	hasSouth := southKind > 0
-
-	if wPiece.Piece&piece.King == piece.King {
-		x := (hasWest || TryIsCenter(westPiece)) && (hasEast || TryIsCenter(eastPiece))
-		y := (hasNorth || TryIsCenter(northPiece)) && (hasSouth || TryIsCenter(southPiece))
-
-		return x && y
+	if err != nil {
+		g.PlayerErr = err
+		return
 	}
 
-	x := hasWest && hasEast
-	y := hasNorth && hasSouth
+	g.SyncBoard()
 
-	return x || y
-
-}
-
-func (g *Game) UpdateWesternNeighbor() {
-	westKind, westSquare := g.GetWesternNeighbor(g.Selected)
-
-	if westKind > 0 && g.IsSandwiched(westSquare) {
-		if westSquare.Piece&piece.King == piece.King {
-			g.Win = true
-		} else if westSquare.Piece&piece.BlackPawn == piece.BlackPawn {
-			g.BlackPawns--
-		} else {
-			g.WhitePawns--
+	if w, ok := g.Players[g.Position.ToMove()].(player.Watcher); ok {
+		if err := w.Watch(move); err != nil {
+			g.PlayerErr = err
 		}
-
-		westSquare.RemovePiece()
 	}
 }
 
-func (g *Game) UpdateEasternNeighbor() {
-	eastKind, eastSquare := g.GetEasternNeighbor(g.Selected)
-
-	if eastKind > 0 && g.IsSandwiched(eastSquare) {
-		if eastSquare.Piece&piece.King == piece.King {
-			g.Win = true
-		} else if eastSquare.Piece&piece.BlackPawn == piece.BlackPawn {
-			g.BlackPawns--
-		} else {
-			g.WhitePawns--
-		}
-
-		eastSquare.RemovePiece()
+func (g *Game) StopWaiting() {
+	if g.cancelMove != nil {
+		g.cancelMove()
 	}
-}
-
-func (g *Game) UpdateNorthernNeighbor() {
-	northKind, northSquare := g.GetNorthernNeighbor(g.Selected)
 
-	if northKind > 0 && g.IsSandwiched(northSquare) {
-		if northSquare.Piece&piece.King == piece.King {
-			g.Win = true
-		} else if northSquare.Piece == piece.BlackPawn {
-			g.BlackPawns--
-		} else {
-			g.WhitePawns--
-		}
-
-		northSquare.RemovePiece()
-	}
+	g.pending, g.cancelMove = nil, nil
 }
 
-func (g *Game) UpdateSouthernNeighbor() {
-	southKind, southSquare := g.GetSouthernNeighbor(g.Selected)
-
-	if southKind > 0 && g.IsSandwiched(southSquare) {
-		if southSquare.Piece&piece.King == piece.King {
-			g.Win = true
-		} else if southSquare.Piece&piece.BlackPawn == piece.BlackPawn {
-			g.BlackPawns--
-		} else {
-			g.WhitePawns--
-		}
-
-		southSquare.RemovePiece()
-	}
+func ToCoord(s *square.Square) rules.Coord {
+	return rules.Coord{Row: int(square.ToRowOrCol(s.Y)), Col: int(square.ToRowOrCol(s.X))}
 }
 
-func (g *Game) Restart() {
-	g.BlackPawns = totalBlackPawns
-	g.WhitePawns = totalWhitePawns
-	g.MovePhase = 0
-
-	g.BlacksTurn = true
-	g.ShouldHighlightSelected = false
-	g.Win = false
-
-	g.Board = board.NewBoard()
+// SyncBoard copies the pieces of the rules position onto the drawn squares.
+func (g *Game) SyncBoard() {
+	l := g.Position.Layout()
+	g.Board.SetLayout(&l)
 }
 
 func (g *Game) Update() {
 	if raylib.IsMouseButtonPressed(raylib.MouseLeftButton) {
-		if g.Win {
+		if g.Outcome.IsOver() {
 			x := raylib.GetMouseX()
 			y := raylib.GetMouseY()
 
 			if (x >= padLeft(g.RestartBtnX) && x < padRight(g.RestartBtnX+g.RestartBtnWidth-1)) &&
 				(y >= g.RestartBtnY && y < (g.RestartBtnY+fontSize-1)) {
-				g.Restart()
-			} else {
-				return
+				g.RestartClicked()
 			}
-		}
-
-		g.SelectSquare()
-		g.ValidateMove()
-
-		if g.MovePhase == 5 {
-			// Swap piece to new location
-			g.Selected.AddPiece(g.PrevSelected.Piece)
-			g.PrevSelected.RemovePiece()
 
-			g.UpdateWesternNeighbor()
-			g.UpdateEasternNeighbor()
-			g.UpdateNorthernNeighbor()
-			g.UpdateSouthernNeighbor()
+			return
+		}
 
-			g.BlacksTurn = !g.BlacksTurn // toggle turn order
-			g.MovePhase = 0
+		if g.IsMousesTurn() {
+			g.HandleClick(g.SquareUnderMouse())
 		}
 	}
 
-	if g.KingHasReachedACorner() || g.BlackPawns == 0 || g.WhitePawns == 0 {
-		g.Win = true
-	}
+	g.UpdateOnline()
+	g.UpdatePlayers()
+	g.Outcome = g.Position.Outcome()
 }
 
 func (g *Game) DrawPieces(wPiece *square.Square) {
@@ -558,11 +375,36 @@ func (g *Game) DrawPieces(wPiece *square.Square) {
 	}
 }
 
+// DrawBackground draws a plain checkered board for variants the embedded
+// background image does not fit.
+func (g *Game) DrawBackground() {
+	if g.Board.SquaresPerRow == backgroundSquaresPerRow {
+		raylib.DrawTexture(g.BoardBackground, 0, 0, raylib.RayWhite)
+		return
+	}
+
+	for row := 0; row < g.Position.Size(); row++ {
+		for col := 0; col < g.Position.Size(); col++ {
+			c := rules.Coord{Row: row, Col: col}
+			color := raylib.Beige
+
+			switch {
+			case g.Position.IsThrone(c) || g.Position.IsKingsCorner(c):
+				color = raylib.DarkBrown
+			case (row+col)%2 == 1:
+				color = raylib.Brown
+			}
+
+			raylib.DrawRectangle(int32(col)*square.SquareSize, int32(row)*square.SquareSize, square.SquareSize, square.SquareSize, color)
+		}
+	}
+}
+
 func (g *Game) DrawBoard() {
-	raylib.DrawTexture(g.BoardBackground, 0, 0, raylib.RayWhite)
+	g.DrawBackground()
 
-	for i := 0; i < square.SquaresPerRow; i++ {
-		for j := 0; j < square.SquaresPerRow; j++ {
+	for i := int32(0); i < g.Board.SquaresPerRow; i++ {
+		for j := int32(0); j < g.Board.SquaresPerRow; j++ {
 			s := &g.Board.Squares[i][j]
 
 			if s.HasPiece() {
@@ -577,15 +419,34 @@ func (g *Game) DrawTurnMsg() {
 
 	turnMsg := BlacksTurnMsg
 	turnColor := raylib.Black
+	turnX := g.TurnMsgX
 
-	if !g.BlacksTurn {
+	switch {
+	case g.Notice != "":
+		turnMsg = g.Notice
+		turnColor = raylib.DarkGray
+		turnX = g.ScreenWidth/2 - raylib.MeasureText(turnMsg, fontSize)/2
+	case !g.Position.BlacksTurn:
 		turnMsg = WhitesTurnMsg
 		turnColor = raylib.White
 	}
 
-	raylib.DrawText(turnMsg, g.TurnMsgX+1, g.MsgY+1, fontSize, raylib.Gray)
-	raylib.DrawText(turnMsg, g.TurnMsgX-1, g.MsgY-1, fontSize, raylib.Gray)
-	raylib.DrawText(turnMsg, g.TurnMsgX, g.MsgY, fontSize, turnColor)
+	if clock := g.ClockText(g.Position.ToMove()); clock != "" {
+		turnMsg += " " + clock
+		turnX = g.ScreenWidth/2 - raylib.MeasureText(turnMsg, fontSize)/2
+	}
+
+	raylib.DrawText(turnMsg, turnX+1, g.MsgY+1, fontSize, raylib.Gray)
+	raylib.DrawText(turnMsg, turnX-1, g.MsgY-1, fontSize, raylib.Gray)
+	raylib.DrawText(turnMsg, turnX, g.MsgY, fontSize, turnColor)
+
+	if g.PlayerErr != nil {
+		errMsg := g.PlayerErr.Error()
+		errX := g.ScreenWidth/2 - raylib.MeasureText(errMsg, reasonFontSize)/2
+
+		raylib.DrawText(errMsg, errX+1, g.RestartBtnY+1, reasonFontSize, raylib.Gray)
+		raylib.DrawText(errMsg, errX, g.RestartBtnY, reasonFontSize, raylib.Red)
+	}
 }
 
 func (g *Game) DrawWinMsg() {
@@ -594,14 +455,25 @@ func (g *Game) DrawWinMsg() {
 	winMsg := BlackWinsMsg
 	winColor := raylib.Black
 
-	if g.KingHasReachedACorner() || g.BlackPawns == 0 {
+	switch g.Outcome.Result {
+	case rules.WhiteWins:
 		winMsg = WhiteWinsMsg
 		winColor = raylib.White
+	case rules.Draw:
+		winMsg = DrawMsg
+		winColor = raylib.DarkGray
 	}
 
 	raylib.DrawText(winMsg, g.WinMsgX+1, g.MsgY+1, fontSize, raylib.Gray)
 	raylib.DrawText(winMsg, g.WinMsgX-1, g.MsgY-1, fontSize, raylib.Gray)
 	raylib.DrawText(winMsg, g.WinMsgX, g.MsgY, fontSize, winColor)
+
+	reasonMsg := g.Outcome.Reason.String()
+	reasonX := g.ScreenWidth/2 - raylib.MeasureText(reasonMsg, reasonFontSize)/2
+	reasonY := g.RestartBtnY + fontSize + reasonFontSize/2
+
+	raylib.DrawText(reasonMsg, reasonX+1, reasonY+1, reasonFontSize, raylib.Gray)
+	raylib.DrawText(reasonMsg, reasonX, reasonY, reasonFontSize, raylib.Gold)
 }
 
 func (g *Game) DrawRestartBtn() {
@@ -616,11 +488,17 @@ func (g *Game) Draw() {
 	raylib.ClearBackground(raylib.Beige)
 
 	g.DrawBoard()
-	if g.ShouldHighlightSelected {
+	if g.Selected != nil {
 		raylib.DrawRectangleLinesEx(raylib.NewRectangle(float32(g.Selected.X), float32(g.Selected.Y), square.SquareSize, square.SquareSize), 1.5, raylib.Green)
+
+		for _, move := range g.SelectedMoves {
+			x := int32(move.To.Col)*square.SquareSize + square.SquareSize/2
+			y := int32(move.To.Row)*square.SquareSize + square.SquareSize/2
+			raylib.DrawCircle(x, y, square.SquareSize/8, raylib.Green)
+		}
 	}
 
-	if g.Win {
+	if g.Outcome.IsOver() {
 		g.DrawWinMsg()
 		g.DrawRestartBtn()
 	} else {
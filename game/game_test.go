@@ -0,0 +1,37 @@
+package game
+
+import (
+	"testing"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestRestartJudgesAdjustedRules(t *testing.T) {
+	// The king starts on the edge, which only wins where edges count.
+	v, err := rules.NewVariant("edge", "Edge", rules.BrandubhRules,
+		"...k...",
+		".......",
+		"...b...",
+		"..bwb..",
+		"...b...",
+		".......",
+		".......",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Game{Variant: v, Mouse: NewMouse()}
+	g.Restart()
+
+	if g.Outcome.IsOver() {
+		t.Fatalf("corner escape: got %v", g.Outcome)
+	}
+
+	g.AdjustRules = func(r *rules.RuleSet) { r.Escape = rules.EdgeEscape }
+	g.Restart()
+
+	if g.Outcome.Result != rules.WhiteWins || g.Position.Outcome() != g.Outcome {
+		t.Errorf("edge escape: got %v, position %v", g.Outcome, g.Position.Outcome())
+	}
+}
@@ -0,0 +1,233 @@
+package game
+
+import (
+	"time"
+
+	raylib "github.com/gen2brain/raylib-go/raylib"
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	player "github.com/technologyfreak/hnefatafl/player"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+const (
+	menuTop       = 20
+	menuRowHeight = 40
+	menuFontSize  = 20
+)
+
+const (
+	NewGameMsg   = "New Game"
+	StartBtnText = "Start"
+)
+
+// Opponent is the side the computer takes, if any.
+type Opponent uint8
+
+const (
+	NoComputer Opponent = iota
+	ComputerPlaysBlack
+	ComputerPlaysWhite
+)
+
+var opponentNames = [...]string{
+	NoComputer:         "nobody",
+	ComputerPlaysBlack: "black",
+	ComputerPlaysWhite: "white",
+}
+
+func (o Opponent) String() string {
+	return opponentNames[o]
+}
+
+// Engines are the built-in engines; "otep" is offered as well when the menu
+// has an External player.
+var Engines = [...]string{"alphabeta", "mcts"}
+
+// Menu holds the choices on the new game screen.
+type Menu struct {
+	Variant  *rules.Variant
+	Opponent Opponent
+	Engine   string
+	Level    ai.Level
+	Style    ai.Style
+
+	// Workers is how many goroutines the alphabeta engine searches with.
+	Workers int
+
+	// Time, Depth and Playouts override what the level sets, unless zero.
+	Time     time.Duration
+	Depth    int
+	Playouts int
+
+	// External is an engine running outside the game, such as an OTEP
+	// client.
+	External player.Player
+}
+
+func (m *Menu) engines() []string {
+	engines := Engines[:]
+	if m.External != nil {
+		engines = append(engines, "otep")
+	}
+
+	return engines
+}
+
+// nextEngine moves on to the next engine the menu offers.
+func (m *Menu) nextEngine() {
+	engines := m.engines()
+
+	for i, e := range engines {
+		if e == m.Engine {
+			m.Engine = engines[(i+1)%len(engines)]
+			return
+		}
+	}
+
+	m.Engine = engines[0]
+}
+
+type menuRow struct {
+	label string
+	value func(m *Menu) string
+	next  func(m *Menu)
+}
+
+var menuRows = [...]menuRow{
+	{
+		label: "Variant",
+		value: func(m *Menu) string { return m.Variant.Name },
+		next: func(m *Menu) {
+			for i, v := range rules.Variants {
+				if v == m.Variant {
+					m.Variant = rules.Variants[(i+1)%len(rules.Variants)]
+					return
+				}
+			}
+
+			m.Variant = rules.Variants[0]
+		},
+	},
+	{
+		label: "Computer plays",
+		value: func(m *Menu) string { return m.Opponent.String() },
+		next:  func(m *Menu) { m.Opponent = (m.Opponent + 1) % Opponent(len(opponentNames)) },
+	},
+	{
+		label: "Engine",
+		value: func(m *Menu) string { return m.Engine },
+		next:  (*Menu).nextEngine,
+	},
+	{
+		label: "Level",
+		value: func(m *Menu) string { return m.Level.String() },
+		next:  func(m *Menu) { m.Level = (m.Level + 1) % ai.Level(len(ai.Levels)) },
+	},
+	{
+		label: "Style",
+		value: func(m *Menu) string { return m.Style.String() },
+		next:  func(m *Menu) { m.Style = (m.Style + 1) % ai.Style(len(ai.Styles)) },
+	},
+}
+
+// Players returns the players the menu asks for, leaving the sides for the
+// mouse nil.
+func (m *Menu) Players() [2]player.Player {
+	var players [2]player.Player
+
+	if m.Opponent == NoComputer {
+		return players
+	}
+
+	side := rules.Black
+	if m.Opponent == ComputerPlaysWhite {
+		side = rules.White
+	}
+
+	switch m.Engine {
+	case "otep":
+		players[side] = m.External
+	case "mcts":
+		mcts := m.Level.MCTS()
+
+		if m.Time > 0 {
+			mcts.Time = m.Time
+		}
+
+		if m.Playouts > 0 {
+			mcts.Playouts = m.Playouts
+		}
+
+		players[side] = player.NewEngine(mcts)
+	default:
+		searcher := m.Level.Searcher(m.Style)
+		searcher.Workers = m.Workers
+
+		if m.Time > 0 {
+			searcher.Time = m.Time
+		}
+
+		if m.Depth > 0 {
+			searcher.MaxDepth = m.Depth
+		}
+
+		players[side] = player.NewEngine(searcher)
+	}
+
+	return players
+}
+
+func (g *Game) ShowMenu() {
+	g.StopWaiting()
+	g.InMenu = true
+}
+
+func menuRowY(row int) int32 {
+	return menuTop + int32(row+1)*menuRowHeight
+}
+
+func (g *Game) UpdateMenu() {
+	if !raylib.IsMouseButtonPressed(raylib.MouseLeftButton) {
+		return
+	}
+
+	y := raylib.GetMouseY() - menuRowY(0) + menuFontSize/4
+	if y < 0 {
+		return
+	}
+
+	row := int(y / menuRowHeight)
+
+	switch {
+	case row < len(menuRows):
+		menuRows[row].next(&g.Menu)
+	case row == len(menuRows):
+		g.NewGame()
+	}
+}
+
+func (g *Game) DrawMenu() {
+	raylib.BeginDrawing()
+	raylib.ClearBackground(raylib.Beige)
+
+	titleX := g.ScreenWidth/2 - raylib.MeasureText(NewGameMsg, fontSize)/2
+	raylib.DrawText(NewGameMsg, titleX+1, menuTop+1, fontSize, raylib.Gray)
+	raylib.DrawText(NewGameMsg, titleX, menuTop, fontSize, raylib.Black)
+
+	for i, row := range menuRows {
+		text := row.label + ": " + row.value(&g.Menu)
+		x := g.ScreenWidth/2 - raylib.MeasureText(text, menuFontSize)/2
+
+		raylib.DrawRectangle(leftPadding, menuRowY(i)-menuFontSize/4, g.ScreenWidth-2*leftPadding, menuFontSize*3/2, raylib.Brown)
+		raylib.DrawText(text, x, menuRowY(i), menuFontSize, raylib.RayWhite)
+	}
+
+	startY := menuRowY(len(menuRows))
+	startX := g.ScreenWidth/2 - raylib.MeasureText(StartBtnText, fontSize)/2
+
+	raylib.DrawRectangle(leftPadding, startY-fontSize/4, g.ScreenWidth-2*leftPadding, fontSize*3/2, raylib.DarkPurple)
+	raylib.DrawText(StartBtnText, startX+1, startY+1, fontSize, raylib.Gray)
+	raylib.DrawText(StartBtnText, startX, startY, fontSize, raylib.Gold)
+
+	raylib.EndDrawing()
+}
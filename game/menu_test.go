@@ -0,0 +1,62 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	player "github.com/technologyfreak/hnefatafl/player"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestEngineRow(t *testing.T) {
+	cycle := func(m *Menu) []string {
+		var seen []string
+
+		for i := 0; i < 4; i++ {
+			m.nextEngine()
+			seen = append(seen, m.Engine)
+		}
+
+		return seen
+	}
+
+	m := &Menu{Engine: "alphabeta"}
+	if got := cycle(m); got[0] != "mcts" || got[1] != "alphabeta" || got[2] != "mcts" || got[3] != "alphabeta" {
+		t.Errorf("without an external engine got %v", got)
+	}
+
+	external := player.NewReplay(nil)
+
+	m = &Menu{Engine: "otep", Opponent: ComputerPlaysWhite, External: external}
+	if players := m.Players(); players[rules.White] != external || players[rules.Black] != nil {
+		t.Errorf("got players %v", players)
+	}
+
+	if got := cycle(m); got[0] != "alphabeta" || got[1] != "mcts" || got[2] != "otep" || got[3] != "alphabeta" {
+		t.Errorf("with an external engine got %v", got)
+	}
+}
+
+func TestMenuOverridesLevel(t *testing.T) {
+	m := &Menu{Engine: "alphabeta", Opponent: ComputerPlaysBlack, Level: ai.Easy, Time: time.Minute, Depth: 9, Playouts: 7}
+
+	searcher := m.Players()[rules.Black].(*player.Engine).Engine.(*ai.Searcher)
+	if searcher.Time != time.Minute || searcher.MaxDepth != 9 {
+		t.Errorf("alphabeta got %v and depth %v", searcher.Time, searcher.MaxDepth)
+	}
+
+	m.Engine = "mcts"
+
+	mcts := m.Players()[rules.Black].(*player.Engine).Engine.(*ai.MCTS)
+	if mcts.Time != time.Minute || mcts.Playouts != 7 {
+		t.Errorf("mcts got %v and %v playouts", mcts.Time, mcts.Playouts)
+	}
+
+	m = &Menu{Engine: "alphabeta", Opponent: ComputerPlaysBlack, Level: ai.Easy}
+
+	searcher = m.Players()[rules.Black].(*player.Engine).Engine.(*ai.Searcher)
+	if want := ai.Easy.Searcher(ai.Balanced); searcher.Time != want.Time || searcher.MaxDepth != want.MaxDepth {
+		t.Errorf("without overrides got %v and depth %v", searcher.Time, searcher.MaxDepth)
+	}
+}
@@ -0,0 +1,34 @@
+package game
+
+import (
+	"context"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Mouse is the player sitting at this computer. HandleClick passes it the
+// moves made on the board.
+type Mouse struct {
+	moves chan rules.Move
+}
+
+func NewMouse() *Mouse {
+	return &Mouse{moves: make(chan rules.Move, 1)}
+}
+
+func (m *Mouse) NextMove(ctx context.Context, p rules.Position) (rules.Move, error) {
+	select {
+	case <-ctx.Done():
+		return rules.Move{}, ctx.Err()
+	case move := <-m.moves:
+		return move, nil
+	}
+}
+
+// Play hands over a move; it is dropped if one is already waiting.
+func (m *Mouse) Play(move rules.Move) {
+	select {
+	case m.moves <- move:
+	default:
+	}
+}
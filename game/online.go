@@ -0,0 +1,296 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	board "github.com/technologyfreak/hnefatafl/board"
+	netplay "github.com/technologyfreak/hnefatafl/netplay"
+	player "github.com/technologyfreak/hnefatafl/player"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+const (
+	WaitingMsg    = "Waiting For An Opponent"
+	InviteCodeMsg = "Invite Code: "
+	AwayMsg       = "Waiting For A Player To Reconnect"
+)
+
+// A dropped connection is dialed again every reconnectDelay, up to
+// reconnectAttempts times, to resume the game.
+const (
+	reconnectAttempts = 30
+	reconnectDelay    = 2 * time.Second
+)
+
+var (
+	ErrDisconnected = errors.New("lost the connection to the server")
+	ErrReconnecting = errors.New("lost the connection, reconnecting")
+)
+
+// Online is a game played through a netplay server. The mouse plays Side
+// against a player.Remote standing in for the server, and both sides are
+// Remotes while Watching; the server says where the pieces stand.
+type Online struct {
+	*netplay.Client
+
+	// Addr is the server to dial again if the connection drops during a
+	// game, and Session what resumes the game there.
+	Addr    string
+	Session string
+
+	// redialed delivers the new connection, or nil when reconnecting
+	// failed.
+	redialed chan *netplay.Client
+
+	Side     rules.Side
+	Playing  bool
+	Watching bool
+
+	// Room is the room the player opened, while it waits for a guest.
+	Room string
+
+	// Remaining is each side's time at Updated, for timed games.
+	Remaining []time.Duration
+	Updated   time.Time
+
+	// seen is how many moves of the game the server has told of, once
+	// synced with the state a game starts or resumes with.
+	seen   int
+	synced bool
+}
+
+func NewOnline(c *netplay.Client) *Online {
+	return &Online{Client: c}
+}
+
+// UpdateOnline takes in whatever the server has sent since the last frame,
+// when playing online.
+func (g *Game) UpdateOnline() {
+	if g.Online == nil {
+		return
+	}
+
+	if g.Online.redialed != nil {
+		select {
+		case c := <-g.Online.redialed:
+			g.Online.redialed = nil
+
+			if c == nil {
+				g.Online.Playing = false
+				g.Players = [2]player.Player{}
+				g.PlayerErr = ErrDisconnected
+				return
+			}
+
+			g.Online.Client = c
+		default:
+			return
+		}
+	}
+
+	for {
+		select {
+		case m, ok := <-g.Online.Updates():
+			if !ok {
+				g.Reconnect()
+				return
+			}
+
+			g.HandleMessage(m)
+		default:
+			return
+		}
+	}
+}
+
+func (g *Game) HandleMessage(m netplay.Message) {
+	switch m.Type {
+	case netplay.TypeWait, netplay.TypeRoom:
+		g.Online.Playing, g.Online.Watching = false, false
+		g.Online.Room, g.Online.Remaining = m.Room, nil
+		g.Players = [2]player.Player{}
+		g.Restart()
+
+		g.Notice = WaitingMsg
+		if m.Room != "" {
+			g.Notice = InviteCodeMsg + m.Room
+		}
+	case netplay.TypeStart:
+		side, err := netplay.ParseSide(m.Side)
+		if err != nil {
+			g.PlayerErr = err
+			return
+		}
+
+		g.Online.Side, g.Online.Playing, g.Online.Room = side, true, ""
+		g.Online.Session = m.Session
+
+		g.Players[side], g.Players[side.Opponent()] = g.Mouse, player.NewRemote(g.send)
+		g.startOnline()
+	case netplay.TypeWatching:
+		g.Online.Watching = true
+
+		remote := player.NewRemote(nil)
+		g.Players = [2]player.Player{remote, remote}
+		g.startOnline()
+	case netplay.TypeState:
+		g.SyncState(m.State)
+	case netplay.TypeError:
+		g.PlayerErr = errors.New(m.Error)
+	}
+}
+
+// startOnline waits for the state the server follows the start of a game
+// with.
+func (g *Game) startOnline() {
+	g.StopWaiting()
+	g.Online.synced = false
+	g.Notice = ""
+}
+
+// SyncState follows the server's state of the game. The move it tells of
+// next goes to the Remote that made it, to be played like any other;
+// anything else, such as the state a game starts or resumes with, takes
+// the place of the position.
+func (g *Game) SyncState(s *netplay.State) {
+	o := g.Online
+
+	o.Remaining, o.Updated = nil, time.Now()
+	for _, ms := range s.Remaining {
+		o.Remaining = append(o.Remaining, time.Duration(ms)*time.Millisecond)
+	}
+
+	g.Notice = ""
+	if len(s.Away) > 0 && !s.Outcome.IsOver() {
+		g.Notice = AwayMsg
+	}
+
+	g.PlayerErr = nil
+
+	if o.synced && !s.Outcome.IsOver() {
+		switch {
+		case len(s.Moves) == o.seen && g.Position.MoveCount >= o.seen-1 && g.Position.MoveCount <= o.seen:
+			// Nothing new on the board, or the Remote has yet to hand
+			// over the last move.
+			return
+		case len(s.Moves) == o.seen+1 && g.Position.MoveCount == o.seen+1:
+			// A move made here.
+			o.seen++
+			return
+		case len(s.Moves) == o.seen+1 && g.Position.MoveCount == o.seen:
+			r, ok := g.Players[g.Position.ToMove()].(*player.Remote)
+			if m, err := rules.ParseMove(s.Last, g.Position.Size()); ok && err == nil {
+				o.seen++
+				r.Played(m)
+				return
+			}
+		}
+	}
+
+	p, err := s.Position()
+	if err != nil {
+		g.PlayerErr = err
+		return
+	}
+
+	g.StopWaiting()
+
+	if p.Variant != g.Variant {
+		g.Variant = p.Variant
+		g.Board = board.NewBoard(int32(p.Variant.Size))
+		g.Resize()
+	}
+
+	g.Position = p
+	g.SyncBoard()
+	g.SelectSquare(nil)
+
+	o.seen, o.synced = len(s.Moves), true
+}
+
+// send passes the server a move played here.
+func (g *Game) send(m rules.Move) error {
+	if g.Online.redialed != nil {
+		return ErrReconnecting
+	}
+
+	return g.Online.Move(m, g.Position.Size())
+}
+
+// Reconnect dials the server again in the background to resume the game
+// after the connection dropped, or gives up when there is no game to go
+// back to.
+func (g *Game) Reconnect() {
+	o := g.Online
+	o.Close()
+
+	if o.Addr == "" || o.Session == "" || !o.Playing || g.Outcome.IsOver() {
+		o.Playing = false
+		g.Players = [2]player.Player{}
+		g.PlayerErr = ErrDisconnected
+		return
+	}
+
+	g.PlayerErr = ErrReconnecting
+	o.redialed = make(chan *netplay.Client, 1)
+
+	go func(addr, session string, redialed chan<- *netplay.Client) {
+		for i := 0; i < reconnectAttempts; i++ {
+			time.Sleep(reconnectDelay)
+
+			c, err := netplay.Dial(addr)
+			if err != nil {
+				continue
+			}
+
+			if err := c.Resume(session); err != nil {
+				c.Close()
+				continue
+			}
+
+			redialed <- c
+			return
+		}
+
+		redialed <- nil
+	}(o.Addr, o.Session, o.redialed)
+}
+
+// RestartClicked goes back to the menu, or online asks the server for
+// another game. Spectators leave the server for the menu.
+func (g *Game) RestartClicked() {
+	if g.Online != nil && !g.Online.Watching {
+		if err := g.Online.Again(); err != nil {
+			g.PlayerErr = err
+		}
+
+		return
+	}
+
+	if g.Online != nil {
+		g.Online.Close()
+		g.Online = nil
+		g.Players = [2]player.Player{}
+	}
+
+	g.ShowMenu()
+}
+
+// ClockText shows the time left for side, counting down while it is to
+// move, or nothing for an untimed game.
+func (g *Game) ClockText(side rules.Side) string {
+	if g.Online == nil || len(g.Online.Remaining) != 2 {
+		return ""
+	}
+
+	left := g.Online.Remaining[side]
+	if side == g.Position.ToMove() && !g.Outcome.IsOver() {
+		left -= time.Since(g.Online.Updated)
+	}
+
+	left = max(left, 0).Round(time.Second)
+
+	return fmt.Sprintf("%d:%02d", int(left.Minutes()), int(left.Seconds())%60)
+}
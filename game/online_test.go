@@ -0,0 +1,82 @@
+package game
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	netplay "github.com/technologyfreak/hnefatafl/netplay"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestOnlinePlaysThroughPlayers(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go (&netplay.Server{Variant: rules.Brandubh}).Serve(l)
+
+	var games []*Game
+
+	for i := 0; i < 2; i++ {
+		c, err := netplay.Dial(l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+
+		g := &Game{Variant: rules.Brandubh, Mouse: NewMouse(), Online: NewOnline(c)}
+		g.Restart()
+		c.Quick("", "")
+
+		games = append(games, g)
+	}
+
+	// pump runs the games as their frames would, minus the window, until
+	// done says so.
+	pump := func(done func() bool) {
+		t.Helper()
+
+		for deadline := time.Now().Add(5 * time.Second); !done(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out")
+			}
+
+			for _, g := range games {
+				g.UpdateOnline()
+				g.UpdatePlayers()
+				g.Outcome = g.Position.Outcome()
+			}
+		}
+	}
+
+	pump(func() bool { return games[0].Online.synced && games[1].Online.synced })
+
+	black, white := games[0], games[1]
+	if black.Online.Side != rules.Black {
+		black, white = white, black
+	}
+
+	if !black.IsMousesTurn() || white.IsMousesTurn() {
+		t.Fatal("only black's mouse should be able to move")
+	}
+
+	black.Mouse.Play(rules.Move{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 1}})
+	pump(func() bool { return black.Online.seen == 1 && white.Position.MoveCount == 1 })
+
+	white.Mouse.Play(rules.Move{From: rules.Coord{Row: 3, Col: 2}, To: rules.Coord{Row: 1, Col: 2}})
+	pump(func() bool { return white.Online.seen == 2 && black.Position.MoveCount == 2 })
+
+	if black.Position.Layout() != white.Position.Layout() || !black.IsMousesTurn() {
+		t.Fatal("the games went different ways")
+	}
+
+	white.Online.Resign()
+	pump(func() bool { return black.Outcome.IsOver() && white.Outcome.IsOver() })
+
+	if want := (rules.Outcome{Result: rules.BlackWins, Reason: rules.ReasonResignation}); black.Outcome != want || white.Outcome != want {
+		t.Errorf("got %v and %v", black.Outcome, white.Outcome)
+	}
+}
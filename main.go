@@ -1,10 +1,233 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
 	game "github.com/technologyfreak/hnefatafl/game"
+	netplay "github.com/technologyfreak/hnefatafl/netplay"
+	otep "github.com/technologyfreak/hnefatafl/otep"
+	player "github.com/technologyfreak/hnefatafl/player"
+	rules "github.com/technologyfreak/hnefatafl/rules"
 )
 
 func main() {
+	variantID := flag.String("variant", rules.Copenhagen.ID, "variant to play")
+	kingArmed := flag.Bool("armed-king", false, "let the king take part in captures (default: variant's rules)")
+	kingCapture := flag.String("king-capture", "", "sides needed to capture the king: 2, 4 or 4-at-throne (default: variant's rules)")
+	escape := flag.String("escape", "", "where the king escapes: corner or edge (default: variant's rules)")
+	hostileThrone := flag.Bool("hostile-throne", false, "make the empty throne hostile to white (default: variant's rules)")
+	passThrone := flag.Bool("pass-throne", false, "let pieces pass through the empty throne (default: variant's rules)")
+	edgeForts := flag.Bool("edge-forts", false, "let white win with an uncapturable fort around the king on the edge (default: variant's rules)")
+	repetition := flag.String("repetition", "", "what threefold repetition does: allowed, draw or lose (default: variant's rules)")
+	moveLimit := flag.Int("move-limit", 0, "draw the game after this many moves, 0 for no limit (default: variant's rules)")
+	shieldwall := flag.Bool("shieldwall", false, "allow shieldwall captures along the board edge (default: variant's rules)")
+	aiSide := flag.String("ai", "none", "side the computer plays: none, black or white (can be changed on the menu)")
+	aiWorkers := flag.Int("ai-workers", runtime.NumCPU(), "goroutines the alphabeta engine searches with")
+	aiTime := flag.Duration("ai-time", 0, "how long the computer thinks per move (default: level's)")
+	aiDepth := flag.Int("ai-depth", 0, "deepest the alphabeta engine searches, in plies (default: level's)")
+	aiPlayouts := flag.Int("ai-playouts", 0, "random games the mcts engine plays per move (default: level's)")
+	engine := flag.String("engine", "alphabeta", "how the computer picks moves: alphabeta, mcts or otep (can be changed on the menu)")
+	otepEngine := flag.String("otep-engine", "", "command line of an external OTEP engine to offer as the otep engine")
+	level := flag.String("level", "hard", "how well the computer plays: beginner, easy, medium or hard (can be changed on the menu)")
+	style := flag.String("style", "balanced", "how the computer likes to play: balanced, aggressive or runner (can be changed on the menu)")
+	replay := flag.String("replay", "", "file of moves, one per line such as a4-a7, to play back")
+	connect := flag.String("connect", "", "address of a game server to play on, such as example.com:7373")
+	join := flag.String("join", "", "with -connect, the invite code of a room to join")
+	host := flag.Bool("host", false, "with -connect, open a private room and wait for a guest")
+	watch := flag.String("watch", "", "with -connect, the code of a game to watch")
+	public := flag.Bool("public", false, "with -host, list the room in the lobby")
+	side := flag.String("side", "", "with -host, the side to play: black or white (default: chance)")
+	clock := flag.String("clock", "none", "with -connect, the time control such as 5+3 for minutes plus seconds a move")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %v:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), "\nVariants:")
+
+		for _, v := range rules.Variants {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %-16v%v\n", v.ID, v)
+		}
+	}
+
+	flag.Parse()
+
+	variant, ok := rules.VariantByID(*variantID)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown variant %q\n", *variantID)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Only flags given on the command line override the variant's rules.
+	adjustRules := func(r *rules.RuleSet) error {
+		var err error
+
+		flag.Visit(func(f *flag.Flag) {
+			if err != nil {
+				return
+			}
+
+			switch f.Name {
+			case "armed-king":
+				r.KingArmed = *kingArmed
+			case "king-capture":
+				r.KingCapture, err = rules.ParseKingCapture(*kingCapture)
+			case "escape":
+				r.Escape, err = rules.ParseEscape(*escape)
+			case "hostile-throne":
+				r.EmptyThroneHostileToDefenders = *hostileThrone
+			case "pass-throne":
+				r.PassThroughThrone = *passThrone
+			case "shieldwall":
+				r.Shieldwall = *shieldwall
+			case "edge-forts":
+				r.EdgeForts = *edgeForts
+			case "repetition":
+				r.Repetition, err = rules.ParseRepetition(*repetition)
+			case "move-limit":
+				r.MoveLimit = *moveLimit
+			}
+		})
+
+		return err
+	}
+
+	ruleSet := variant.Rules
+	if err := adjustRules(&ruleSet); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	menu := game.Menu{Variant: variant, Workers: *aiWorkers, Time: *aiTime, Depth: *aiDepth, Playouts: *aiPlayouts}
+
+	var err error
+
+	if menu.Level, err = ai.ParseLevel(*level); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if menu.Style, err = ai.ParseStyle(*style); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if args := strings.Fields(*otepEngine); len(args) > 0 {
+		client, err := otep.Start(args[0], args[1:]...)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer client.Close()
+
+		menu.External = client
+	}
+
+	switch {
+	case *engine == "alphabeta", *engine == "mcts":
+		menu.Engine = *engine
+	case *engine == "otep" && menu.External != nil:
+		menu.Engine = *engine
+	case *engine == "otep":
+		fmt.Fprintln(os.Stderr, "the otep engine needs -otep-engine")
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown engine %q\n", *engine)
+		os.Exit(2)
+	}
+
+	switch *aiSide {
+	case "none":
+		menu.Opponent = game.NoComputer
+	case "black":
+		menu.Opponent = game.ComputerPlaysBlack
+	case "white":
+		menu.Opponent = game.ComputerPlaysWhite
+	default:
+		fmt.Fprintf(os.Stderr, "unknown side %q\n", *aiSide)
+		os.Exit(2)
+	}
+
+	var online *game.Online
+
+	if _, err := netplay.ParseTimeControl(*clock); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *connect != "" {
+		client, err := netplay.Dial(*connect)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer client.Close()
+
+		switch {
+		case *join != "":
+			err = client.Join(*join)
+		case *watch != "":
+			err = client.Watch(*watch)
+		case *host:
+			err = client.Create(variant.ID, *clock, *side, !*public)
+		default:
+			err = client.Quick(variant.ID, *clock)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		online = game.NewOnline(client)
+		online.Addr = *connect
+	}
+
 	game := new(game.Game)
+	game.Menu = menu
+	game.Online = online
+
+	if *replay != "" {
+		moves, err := readMoves(*replay, variant.Size)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		r := player.NewReplay(moves)
+		game.Players = [2]player.Player{r, r}
+	}
+
+	game.Variant = variant
+	game.AdjustRules = func(r *rules.RuleSet) { adjustRules(r) }
 	game.Init()
 }
+
+func readMoves(path string, size int) ([]rules.Move, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []rules.Move
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		m, err := rules.ParseMove(line, size)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", path, err)
+		}
+
+		moves = append(moves, m)
+	}
+
+	return moves, nil
+}
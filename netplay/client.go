@@ -0,0 +1,96 @@
+package netplay
+
+import (
+	"io"
+	"net"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Client is a player's connection to a server.
+type Client struct {
+	*conn
+	updates chan Message
+}
+
+func Dial(addr string) (*Client, error) {
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(c), nil
+}
+
+// NewClient talks to a server over rwc.
+func NewClient(rwc io.ReadWriteCloser) *Client {
+	c := &Client{conn: newConn(rwc), updates: make(chan Message, 16)}
+	go c.receive()
+
+	return c
+}
+
+func (c *Client) receive() {
+	defer close(c.updates)
+
+	for {
+		m, err := c.read()
+		if err != nil {
+			return
+		}
+
+		c.updates <- m
+	}
+}
+
+// Updates delivers the messages the server sends, and is closed when the
+// connection is lost.
+func (c *Client) Updates() <-chan Message {
+	return c.updates
+}
+
+// Create opens a room for a game of variant with clock, in which the
+// client plays side, or a side left to chance when side is empty.
+func (c *Client) Create(variant, clock, side string, private bool) error {
+	return c.write(Message{Type: TypeCreate, Variant: variant, Clock: clock, Side: side, Private: private})
+}
+
+func (c *Client) Join(room string) error {
+	return c.write(Message{Type: TypeJoin, Room: room})
+}
+
+// Quick looks for anyone else after a game of variant with clock.
+func (c *Client) Quick(variant, clock string) error {
+	return c.write(Message{Type: TypeQuick, Variant: variant, Clock: clock})
+}
+
+// Watch follows the game called room as a spectator.
+func (c *Client) Watch(room string) error {
+	return c.write(Message{Type: TypeWatch, Room: room})
+}
+
+func (c *Client) Cancel() error {
+	return c.write(Message{Type: TypeCancel})
+}
+
+func (c *Client) Move(m rules.Move, size int) error {
+	return c.write(Message{Type: TypeMove, Move: rules.FormatMove(m, size)})
+}
+
+func (c *Client) Resign() error {
+	return c.write(Message{Type: TypeResign})
+}
+
+// Resume takes back a seat in the game the server gave session for.
+func (c *Client) Resume(session string) error {
+	return c.write(Message{Type: TypeResume, Session: session})
+}
+
+// Again asks for a new game once the last one is over.
+func (c *Client) Again() error {
+	return c.write(Message{Type: TypeAgain})
+}
+
+func (c *Client) Close() error {
+	return c.rwc.Close()
+}
@@ -0,0 +1,72 @@
+package netplay
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeControl is how long each side has for the whole game, and how much
+// each move gives back. The zero TimeControl is an untimed game.
+type TimeControl struct {
+	Base      time.Duration
+	Increment time.Duration
+}
+
+func (tc TimeControl) IsTimed() bool {
+	return tc.Base > 0
+}
+
+// String writes tc as minutes plus seconds of increment, such as 5+3, or
+// "none" for an untimed game.
+func (tc TimeControl) String() string {
+	if !tc.IsTimed() {
+		return "none"
+	}
+
+	minutes := strconv.FormatFloat(tc.Base.Minutes(), 'f', -1, 64)
+
+	return minutes + "+" + strconv.FormatFloat(tc.Increment.Seconds(), 'f', -1, 64)
+}
+
+func ParseTimeControl(s string) (TimeControl, error) {
+	if s == "" || s == "none" {
+		return TimeControl{}, nil
+	}
+
+	base, inc, _ := strings.Cut(s, "+")
+	if inc == "" {
+		inc = "0"
+	}
+
+	minutes, err := strconv.ParseFloat(base, 64)
+	if err != nil || minutes <= 0 {
+		return TimeControl{}, fmt.Errorf("netplay: bad time control %q", s)
+	}
+
+	seconds, err := strconv.ParseFloat(inc, 64)
+	if err != nil || seconds < 0 {
+		return TimeControl{}, fmt.Errorf("netplay: bad time control %q", s)
+	}
+
+	return TimeControl{
+		Base:      time.Duration(minutes * float64(time.Minute)),
+		Increment: time.Duration(seconds * float64(time.Second)),
+	}, nil
+}
+
+func (tc TimeControl) MarshalText() ([]byte, error) {
+	return []byte(tc.String()), nil
+}
+
+func (tc *TimeControl) UnmarshalText(b []byte) error {
+	parsed, err := ParseTimeControl(string(b))
+	if err != nil {
+		return err
+	}
+
+	*tc = parsed
+
+	return nil
+}
@@ -0,0 +1,282 @@
+package netplay
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	mathrand "math/rand"
+	"sort"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// codeAlphabet leaves out letters and digits that are easily mixed up
+// when an invite code is read out.
+const codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const codeLength = 6
+
+// room is a game waiting for its second player.
+type room struct {
+	Room
+
+	private bool
+	host    *client
+	variant *rules.Variant
+	clock   TimeControl
+}
+
+// seek is a client in the quick-match queue.
+type seek struct {
+	client  *client
+	variant *rules.Variant
+	clock   TimeControl
+}
+
+// newCode returns a room ID that is not taken. s.mu must be held.
+func (s *Server) newCode() string {
+	for {
+		code := make([]byte, codeLength)
+
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+			if err != nil {
+				panic(err)
+			}
+
+			code[i] = codeAlphabet[n.Int64()]
+		}
+
+		_, room := s.rooms[string(code)]
+		_, table := s.tables[string(code)]
+
+		if !room && !table {
+			return string(code)
+		}
+	}
+}
+
+// newSession returns a secret that lets a player take back its seat.
+func newSession() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// lobby lists the rooms open to anyone and the games anyone may watch.
+// s.mu must be held.
+func (s *Server) lobby() Message {
+	m := Message{Type: TypeLobby, Rooms: []Room{}, Games: []Room{}}
+
+	for _, r := range s.rooms {
+		if !r.private {
+			m.Rooms = append(m.Rooms, r.Room)
+		}
+	}
+
+	for _, t := range s.tables {
+		if !t.private {
+			m.Games = append(m.Games, Room{ID: t.id, Variant: t.position.Variant.ID, Clock: t.clock.String()})
+		}
+	}
+
+	sort.Slice(m.Rooms, func(i, j int) bool { return m.Rooms[i].ID < m.Rooms[j].ID })
+	sort.Slice(m.Games, func(i, j int) bool { return m.Games[i].ID < m.Games[j].ID })
+
+	return m
+}
+
+// broadcastLobby tells every client what rooms are open.
+func (s *Server) broadcastLobby() {
+	s.mu.Lock()
+	m := s.lobby()
+
+	clients := make([]*client, 0, len(s.clients))
+	for c := range s.clients {
+		clients = append(clients, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		c.send(m)
+	}
+}
+
+// free gets c ready to start a game, closing its room and taking it out of
+// the queue, and reports whether that changed the lobby. c may not leave
+// a game that is going on. s.mu must be held.
+func (s *Server) free(c *client) (bool, error) {
+	if c.table != nil && !c.table.isOver() {
+		return false, ErrGameGoingOn
+	}
+
+	return s.cancel(c), nil
+}
+
+// cancel closes c's room, takes it out of the queue and stops it
+// watching, and reports whether that changed the lobby. s.mu must be held.
+func (s *Server) cancel(c *client) bool {
+	changed := false
+
+	if c.watching != nil {
+		c.watching.unwatch(c)
+		c.watching = nil
+	}
+
+	if r := c.room; r != nil {
+		delete(s.rooms, r.ID)
+		c.room = nil
+		changed = !r.private
+	}
+
+	if c.seek != nil {
+		for i, sk := range s.queue {
+			if sk == c.seek {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				break
+			}
+		}
+
+		c.seek = nil
+	}
+
+	return changed
+}
+
+func (s *Server) create(c *client, m Message) error {
+	v, tc, err := s.settings(m.Variant, m.Clock)
+	if err != nil {
+		return err
+	}
+
+	if m.Side != "" {
+		if _, err := ParseSide(m.Side); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+
+	changed, err := s.free(c)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	r := &room{
+		Room:    Room{ID: s.newCode(), Variant: v.ID, Clock: tc.String(), Side: m.Side},
+		private: m.Private,
+		host:    c,
+		variant: v,
+		clock:   tc,
+	}
+
+	if s.rooms == nil {
+		s.rooms = make(map[string]*room)
+	}
+
+	s.rooms[r.ID] = r
+	c.room = r
+	s.mu.Unlock()
+
+	c.send(Message{Type: TypeRoom, Room: r.ID, Variant: v.ID, Clock: tc.String(), Private: r.private})
+
+	if changed || !r.private {
+		s.broadcastLobby()
+	}
+
+	return nil
+}
+
+func (s *Server) join(c *client, id string) error {
+	s.mu.Lock()
+
+	r, ok := s.rooms[id]
+	switch {
+	case !ok:
+		s.mu.Unlock()
+		return ErrNoRoom
+	case r.host == c:
+		s.mu.Unlock()
+		return ErrOwnRoom
+	}
+
+	changed, err := s.free(c)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	delete(s.rooms, id)
+	r.host.room = nil
+
+	black, white := r.host, c
+	switch r.Side {
+	case "white":
+		black, white = c, r.host
+	case "":
+		if mathrand.Intn(2) == 1 {
+			black, white = c, r.host
+		}
+	}
+
+	t := s.newTable(r.ID, r.private, r.variant, r.clock, black, white)
+	s.mu.Unlock()
+
+	t.start()
+
+	if changed || !r.private {
+		s.broadcastLobby()
+	}
+
+	return nil
+}
+
+// quick pairs c with the first client in the queue after the same game,
+// or queues c when there is none. Sides are left to chance.
+func (s *Server) quick(c *client, v *rules.Variant, tc TimeControl) error {
+	s.mu.Lock()
+
+	changed, err := s.free(c)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	for i, sk := range s.queue {
+		if sk.variant != v || sk.clock != tc {
+			continue
+		}
+
+		s.queue = append(s.queue[:i], s.queue[i+1:]...)
+		sk.client.seek = nil
+
+		black, white := sk.client, c
+		if mathrand.Intn(2) == 1 {
+			black, white = c, sk.client
+		}
+
+		t := s.newTable(s.newCode(), false, v, tc, black, white)
+		s.mu.Unlock()
+
+		t.start()
+		s.broadcastLobby()
+
+		return nil
+	}
+
+	c.seek = &seek{client: c, variant: v, clock: tc}
+	s.queue = append(s.queue, c.seek)
+	s.mu.Unlock()
+
+	c.send(Message{Type: TypeWait, Variant: v.ID, Clock: tc.String()})
+
+	if changed {
+		s.broadcastLobby()
+	}
+
+	return nil
+}
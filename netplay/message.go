@@ -0,0 +1,302 @@
+// Package netplay hosts games between players on different machines. The
+// server keeps the only real copy of each game and checks every move; the
+// clients send moves and draw the state the server sends back.
+//
+// Messages are JSON objects, one per line.
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	piece "github.com/technologyfreak/hnefatafl/piece"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Message types sent by clients.
+const (
+	// TypeCreate opens a room with a Variant, Clock and the Side its host
+	// wants to play. Private rooms stay out of the lobby; the players
+	// join them with the invite code the server answers with.
+	TypeCreate = "create"
+
+	// TypeJoin takes the other seat in Room.
+	TypeJoin = "join"
+
+	// TypeQuick waits for anyone else after a game of Variant with Clock,
+	// leaving the sides to chance.
+	TypeQuick = "quick"
+
+	// TypeWatch follows the game in Room without playing in it.
+	TypeWatch = "watch"
+
+	// TypeCancel closes the client's room, takes it out of the queue or
+	// stops it watching.
+	TypeCancel = "cancel"
+
+	TypeMove   = "move"
+	TypeResign = "resign"
+
+	// TypeAgain looks for another game like the last one once it is over.
+	TypeAgain = "again"
+
+	// TypeResume takes back the seat of a player whose connection
+	// dropped, given the Session it was started with.
+	TypeResume = "resume"
+)
+
+// Message types sent by the server.
+const (
+	// TypeLobby lists the open rooms. It comes when the client connects
+	// and whenever the list changes.
+	TypeLobby = "lobby"
+
+	// TypeRoom gives the ID of the room the client opened.
+	TypeRoom = "room"
+
+	// TypeWait says the server is looking for an opponent.
+	TypeWait = "wait"
+
+	// TypeStart gives the client its side in a new game, or in the one
+	// it resumed, and the Session to resume it with should the connection
+	// drop. The state of the game follows.
+	TypeStart = "start"
+
+	// TypeWatching says the client is watching the game in Room. The
+	// state of the game follows, and again after every move.
+	TypeWatching = "watching"
+
+	TypeState = "state"
+	TypeError = "error"
+)
+
+type Message struct {
+	Type  string `json:"type"`
+	Move  string `json:"move,omitempty"`
+	Side  string `json:"side,omitempty"`
+	Error string `json:"error,omitempty"`
+	State *State `json:"state,omitempty"`
+
+	Room    string `json:"room,omitempty"`
+	Variant string `json:"variant,omitempty"`
+	Clock   string `json:"clock,omitempty"`
+	Private bool   `json:"private,omitempty"`
+	Rooms   []Room `json:"rooms,omitempty"`
+
+	// Games lists the games being played that anyone may watch.
+	Games []Room `json:"games,omitempty"`
+
+	Session string `json:"session,omitempty"`
+}
+
+// Room is an open game waiting in the lobby for a second player, or a
+// game being played.
+type Room struct {
+	ID      string `json:"id"`
+	Variant string `json:"variant"`
+	Clock   string `json:"clock"`
+
+	// Side is the side the host plays, or empty when it is left to
+	// chance.
+	Side string `json:"side,omitempty"`
+}
+
+// State is everything a client needs to draw a game.
+type State struct {
+	Variant string        `json:"variant"`
+	Rules   rules.RuleSet `json:"rules"`
+
+	// Moves is every move played so far, in rules notation.
+	Moves []string `json:"moves"`
+
+	// Board lists the rows from the top, in the form rules.NewVariant
+	// takes.
+	Board []string `json:"board"`
+
+	ToMove  string        `json:"toMove"`
+	Legal   []string      `json:"legal"`
+	Outcome rules.Outcome `json:"outcome"`
+	Status  string        `json:"status"`
+
+	// Last is the latest move and Captured the squares it took pieces
+	// from.
+	Last     string   `json:"last,omitempty"`
+	Captured []string `json:"captured,omitempty"`
+
+	Spectators int `json:"spectators"`
+
+	// Away lists the sides whose connection dropped, while the server
+	// waits for them to come back.
+	Away []string `json:"away,omitempty"`
+
+	Clock string `json:"clock"`
+
+	// Remaining is the time black and white have left in milliseconds,
+	// when the game is timed.
+	Remaining []int64 `json:"remaining,omitempty"`
+}
+
+func sideName(s rules.Side) string {
+	if s == rules.Black {
+		return "black"
+	}
+
+	return "white"
+}
+
+// ParseSide reads the side given in a start message.
+func ParseSide(s string) (rules.Side, error) {
+	switch s {
+	case "black":
+		return rules.Black, nil
+	case "white":
+		return rules.White, nil
+	}
+
+	return rules.Black, fmt.Errorf("netplay: unknown side %q", s)
+}
+
+// NewState describes p, which was reached by playing moves.
+func NewState(p *rules.Position, moves []rules.Move) *State {
+	size := p.Size()
+
+	s := &State{
+		Variant: p.Variant.ID,
+		Rules:   p.Rules,
+		Moves:   []string{},
+		Board:   make([]string, size),
+		ToMove:  sideName(p.ToMove()),
+		Legal:   []string{},
+		Outcome: p.Outcome(),
+		Status:  p.Outcome().String(),
+	}
+
+	for _, m := range moves {
+		s.Moves = append(s.Moves, rules.FormatMove(m, size))
+	}
+
+	for row := range s.Board {
+		var b strings.Builder
+
+		for col := 0; col < size; col++ {
+			switch kind := p.At(rules.Coord{Row: row, Col: col}); {
+			case kind&piece.King == piece.King:
+				b.WriteByte('k')
+			case kind&piece.BlackPawn == piece.BlackPawn:
+				b.WriteByte('b')
+			case kind&piece.WhitePawn == piece.WhitePawn:
+				b.WriteByte('w')
+			default:
+				b.WriteByte('.')
+			}
+		}
+
+		s.Board[row] = b.String()
+	}
+
+	if !s.Outcome.IsOver() {
+		for _, m := range p.LegalMoves() {
+			s.Legal = append(s.Legal, rules.FormatMove(m, size))
+		}
+	}
+
+	return s
+}
+
+// Position replays the moves of s, giving a position that knows the
+// history of the game as well as where the pieces stand. Games that ended
+// off the board, by resignation, timeout or abandonment, end the same way
+// in the position.
+func (s *State) Position() (rules.Position, error) {
+	v, ok := rules.VariantByID(s.Variant)
+	if !ok {
+		return rules.Position{}, fmt.Errorf("netplay: unknown variant %q", s.Variant)
+	}
+
+	p := rules.NewPositionWithRules(v, s.Rules)
+
+	for _, move := range s.Moves {
+		m, err := rules.ParseMove(move, v.Size)
+		if err != nil {
+			return p, err
+		}
+
+		if err := p.Apply(m); err != nil {
+			return p, fmt.Errorf("netplay: replaying %v: %w", move, err)
+		}
+	}
+
+	if s.Outcome.IsOver() && !p.Outcome().IsOver() {
+		loser := rules.Black
+		if s.Outcome.Result == rules.BlackWins {
+			loser = rules.White
+		}
+
+		switch s.Outcome.Reason {
+		case rules.ReasonResignation:
+			p.Resign(loser)
+		case rules.ReasonTimeout:
+			p.Timeout(loser)
+		case rules.ReasonAbandoned:
+			p.Abandon(loser)
+		}
+	}
+
+	return p, nil
+}
+
+// conn reads and writes messages on a connection. Writes may come from
+// any goroutine.
+type conn struct {
+	rwc     io.ReadWriteCloser
+	scanner *bufio.Scanner
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func newConn(rwc io.ReadWriteCloser) *conn {
+	scanner := bufio.NewScanner(rwc)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	return &conn{rwc: rwc, scanner: scanner, enc: json.NewEncoder(rwc)}
+}
+
+func (c *conn) read() (Message, error) {
+	var m Message
+
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return m, err
+		}
+
+		return m, io.EOF
+	}
+
+	if err := json.Unmarshal(c.scanner.Bytes(), &m); err != nil {
+		return m, badMessage{err}
+	}
+
+	return m, nil
+}
+
+// badMessage is a line that is not a message. The connection can carry on
+// after one.
+type badMessage struct {
+	err error
+}
+
+func (b badMessage) Error() string {
+	return "netplay: bad message: " + b.err.Error()
+}
+
+func (c *conn) write(m Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.enc.Encode(m)
+}
@@ -0,0 +1,617 @@
+package netplay
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+	websocket "github.com/technologyfreak/hnefatafl/websocket"
+)
+
+func listen(t *testing.T, s *Server) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go s.Serve(l)
+
+	return l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *Client {
+	t.Helper()
+
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	expect(t, c, TypeLobby)
+
+	return c
+}
+
+// expect waits for the next message, which must be of type typ. Updates
+// to the lobby, which can come at any time, are skipped unless typ asks
+// for one.
+func expect(t *testing.T, c *Client, typ string) Message {
+	t.Helper()
+
+	for {
+		select {
+		case m, ok := <-c.Updates():
+			if !ok {
+				t.Fatalf("connection closed waiting for %v", typ)
+			}
+
+			if m.Type == TypeLobby && typ != TypeLobby {
+				continue
+			}
+
+			if m.Type != typ {
+				t.Fatalf("got %+v, want %v", m, typ)
+			}
+
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %v", typ)
+		}
+	}
+}
+
+// started reads the start of a game between a and b, and returns them as
+// black and white.
+func started(t *testing.T, a, b *Client) (*Client, *Client) {
+	t.Helper()
+
+	sideA := expect(t, a, TypeStart).Side
+	sideB := expect(t, b, TypeStart).Side
+	expect(t, a, TypeState)
+	expect(t, b, TypeState)
+
+	switch {
+	case sideA == "black" && sideB == "white":
+		return a, b
+	case sideA == "white" && sideB == "black":
+		return b, a
+	}
+
+	t.Fatalf("got sides %v and %v", sideA, sideB)
+
+	return nil, nil
+}
+
+// pair quick-matches two clients and returns them as black and white.
+func pair(t *testing.T, addr string) (*Client, *Client) {
+	t.Helper()
+
+	a := dial(t, addr)
+	a.Quick("", "")
+	expect(t, a, TypeWait)
+
+	b := dial(t, addr)
+	b.Quick("", "")
+
+	return started(t, a, b)
+}
+
+func TestServerRefereesGame(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Brandubh})
+	black, white := pair(t, addr)
+
+	// Only the side to move may move, and only legally.
+	white.write(Message{Type: TypeMove, Move: "c4-c6"})
+	if m := expect(t, white, TypeError); m.Error != ErrNotYourTurn.Error() {
+		t.Errorf("got %v", m.Error)
+	}
+
+	black.write(Message{Type: TypeMove, Move: "d7-d4"})
+	expect(t, black, TypeError)
+
+	black.write(Message{Type: "castle"})
+	expect(t, black, TypeError)
+
+	if err := black.Move(rules.Move{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 1}}, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*Client{black, white} {
+		s := expect(t, c, TypeState).State
+
+		if len(s.Moves) != 1 || s.Moves[0] != "d7-b7" || s.ToMove != "white" || s.Board[0] != ".b....." {
+			t.Fatalf("got %+v", s)
+		}
+
+		p, err := s.Position()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if p.BlacksTurn || p.MoveCount != 1 || len(p.LegalMoves()) != len(s.Legal) {
+			t.Errorf("replayed position does not match %+v", s)
+		}
+	}
+
+	// Asking for another game while this one is going on is refused.
+	white.Again()
+	expect(t, white, TypeError)
+
+	white.Resign()
+
+	for _, c := range []*Client{black, white} {
+		s := expect(t, c, TypeState).State
+
+		if s.Outcome != (rules.Outcome{Result: rules.BlackWins, Reason: rules.ReasonResignation}) || len(s.Legal) != 0 {
+			t.Errorf("got %+v", s)
+		}
+
+		if p, err := s.Position(); err != nil || p.Outcome() != s.Outcome {
+			t.Errorf("replayed outcome %v, %v", p.Outcome(), err)
+		}
+	}
+
+	white.Move(rules.Move{From: rules.Coord{Row: 2, Col: 3}, To: rules.Coord{Row: 2, Col: 0}}, 7)
+	if m := expect(t, white, TypeError); m.Error != ErrGameOver.Error() {
+		t.Errorf("got %v", m.Error)
+	}
+
+	// The winner waits for another game like the last, and the loser
+	// gives one.
+	black.Again()
+	if m := expect(t, black, TypeWait); m.Variant != "brandubh" || m.Clock != "none" {
+		t.Errorf("waiting for %v %v", m.Variant, m.Clock)
+	}
+
+	white.Again()
+	started(t, black, white)
+}
+
+func TestQuickMatchesLikeWithLike(t *testing.T) {
+	addr := listen(t, &Server{})
+
+	a := dial(t, addr)
+	a.Quick("tablut", "5+3")
+	expect(t, a, TypeWait)
+
+	b := dial(t, addr)
+	b.Quick("tablut", "")
+	expect(t, b, TypeWait)
+
+	c := dial(t, addr)
+	c.Quick("brandubh", "5")
+	expect(t, c, TypeWait)
+
+	d := dial(t, addr)
+	d.Quick("tablut", "5+3")
+	started(t, a, d)
+
+	// Changing its mind takes b out of the queue.
+	b.Quick("brandubh", "5+0")
+	black, _ := started(t, b, c)
+
+	black.write(Message{Type: TypeMove, Move: "d7-b7"})
+	if s := expect(t, b, TypeState).State; s.Variant != "brandubh" || s.Clock != "5+0" || len(s.Remaining) != 2 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestRooms(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Brandubh})
+
+	host := dial(t, addr)
+	guest := dial(t, addr)
+
+	host.Create("", "10+5", "white", false)
+	open := expect(t, host, TypeRoom)
+
+	for _, c := range []*Client{host, guest} {
+		rooms := expect(t, c, TypeLobby).Rooms
+		if len(rooms) != 1 || rooms[0] != (Room{ID: open.Room, Variant: "brandubh", Clock: "10+5", Side: "white"}) {
+			t.Fatalf("lobby lists %+v", rooms)
+		}
+	}
+
+	// Opening a private room closes the open one, and keeps the lobby
+	// empty.
+	host.Create("tablut", "", "", true)
+	private := expect(t, host, TypeRoom)
+	if private.Room == open.Room || len(private.Room) != codeLength || !private.Private {
+		t.Fatalf("got %+v", private)
+	}
+
+	for _, c := range []*Client{host, guest} {
+		if rooms := expect(t, c, TypeLobby).Rooms; len(rooms) != 0 {
+			t.Fatalf("lobby lists %+v", rooms)
+		}
+	}
+
+	guest.Join(open.Room)
+	if m := expect(t, guest, TypeError); m.Error != ErrNoRoom.Error() {
+		t.Errorf("got %v", m.Error)
+	}
+
+	host.Join(private.Room)
+	expect(t, host, TypeError)
+
+	guest.Join(private.Room)
+	started(t, host, guest)
+
+	host.Create("", "", "", false)
+	if m := expect(t, host, TypeError); m.Error != ErrGameGoingOn.Error() {
+		t.Errorf("got %v", m.Error)
+	}
+}
+
+func TestHostPicksSide(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Brandubh})
+
+	host := dial(t, addr)
+	host.Create("", "", "white", true)
+	room := expect(t, host, TypeRoom).Room
+
+	guest := dial(t, addr)
+	guest.Join(room)
+
+	if _, white := started(t, host, guest); white != host {
+		t.Errorf("host does not play white")
+	}
+}
+
+func TestFlagFalls(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Brandubh})
+
+	host := dial(t, addr)
+	host.Create("", "0.001", "black", true)
+	room := expect(t, host, TypeRoom).Room
+
+	guest := dial(t, addr)
+	guest.Join(room)
+	started(t, host, guest)
+
+	s := expect(t, guest, TypeState).State
+	if s.Outcome != (rules.Outcome{Result: rules.WhiteWins, Reason: rules.ReasonTimeout}) || s.Remaining[0] != 0 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestTimeControl(t *testing.T) {
+	for s, want := range map[string]TimeControl{
+		"none": {},
+		"5+3":  {Base: 5 * time.Minute, Increment: 3 * time.Second},
+		"0.5":  {Base: 30 * time.Second},
+	} {
+		tc, err := ParseTimeControl(s)
+		if err != nil || tc != want {
+			t.Errorf("%v: got %v, %v", s, tc, err)
+		}
+	}
+
+	if got := (TimeControl{Base: 30 * time.Second}).String(); got != "0.5+0" {
+		t.Errorf("got %v", got)
+	}
+
+	for _, s := range []string{"5-3", "0", "+3", "5+-1"} {
+		if _, err := ParseTimeControl(s); err == nil {
+			t.Errorf("%v parsed", s)
+		}
+	}
+}
+
+func TestLeavingForfeits(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Tablut, Grace: 50 * time.Millisecond})
+	black, white := pair(t, addr)
+
+	black.Close()
+
+	if s := expect(t, white, TypeState).State; len(s.Away) != 1 || s.Away[0] != "black" || s.Outcome.IsOver() {
+		t.Errorf("got %+v", s)
+	}
+
+	s := expect(t, white, TypeState).State
+	if s.Outcome != (rules.Outcome{Result: rules.WhiteWins, Reason: rules.ReasonAbandoned}) || len(s.Away) != 0 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestResume(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Brandubh})
+
+	a := dial(t, addr)
+	a.Quick("", "")
+	expect(t, a, TypeWait)
+
+	b := dial(t, addr)
+	b.Quick("", "")
+
+	players := make(map[string]*Client)
+	sessions := make(map[string]string)
+
+	for _, c := range []*Client{a, b} {
+		m := expect(t, c, TypeStart)
+		players[m.Side], sessions[m.Side] = c, m.Session
+		expect(t, c, TypeState)
+	}
+
+	black, white := players["black"], players["white"]
+	if len(sessions["black"]) != 32 || sessions["black"] == sessions["white"] {
+		t.Fatalf("got sessions %v", sessions)
+	}
+
+	black.write(Message{Type: TypeMove, Move: "d7-b7"})
+	expect(t, black, TypeState)
+	expect(t, white, TypeState)
+
+	black.Close()
+	expect(t, white, TypeState)
+
+	// White plays on while black is away.
+	white.write(Message{Type: TypeMove, Move: "c4-c6"})
+	expect(t, white, TypeState)
+
+	stranger := dial(t, addr)
+	stranger.Resume("not a session")
+	if m := expect(t, stranger, TypeError); m.Error != ErrNoGame.Error() {
+		t.Errorf("got %v", m.Error)
+	}
+
+	back := dial(t, addr)
+	back.Resume(sessions["black"])
+
+	if m := expect(t, back, TypeStart); m.Side != "black" || m.Session != sessions["black"] {
+		t.Errorf("got %+v", m)
+	}
+
+	// The moves black missed are in the snapshot, and replaying them gives
+	// the board the server has.
+	s := expect(t, back, TypeState).State
+	if len(s.Moves) != 2 || s.Last != "c4-c6" || s.ToMove != "black" || len(s.Away) != 0 {
+		t.Fatalf("got %+v", s)
+	}
+
+	p, err := s.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(NewState(&p, nil).Board, "/"); got != strings.Join(s.Board, "/") {
+		t.Errorf("rebuilt %v, want %v", got, strings.Join(s.Board, "/"))
+	}
+
+	expect(t, white, TypeState)
+
+	back.write(Message{Type: TypeMove, Move: "b7-b5"})
+	if s := expect(t, white, TypeState).State; len(s.Moves) != 3 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestWebSocketPlaysDesktop(t *testing.T) {
+	s := &Server{Variant: rules.Brandubh}
+
+	srv := httptest.NewServer(http.HandlerFunc(s.ServeWebSocket))
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	browser := NewClient(&wsStream{conn: ws})
+	defer browser.Close()
+
+	expect(t, browser, TypeLobby)
+	browser.Quick("", "")
+	expect(t, browser, TypeWait)
+
+	desktop := dial(t, listen(t, s))
+	desktop.Quick("", "")
+
+	black, white := started(t, browser, desktop)
+	black.write(Message{Type: TypeMove, Move: "d7-b7"})
+
+	if s := expect(t, white, TypeState).State; len(s.Moves) != 1 {
+		t.Errorf("got %+v", s)
+	}
+}
+
+func TestStateJSON(t *testing.T) {
+	p := rules.NewPosition(rules.Brandubh)
+	m := p.LegalMoves()[0]
+	if err := p.Apply(m); err != nil {
+		t.Fatal(err)
+	}
+	p.Resign(rules.White)
+
+	data, err := json.Marshal(NewState(&p, []rules.Move{m}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The browser client reads these by name.
+	for _, want := range []string{
+		`"outcome":{"result":"black wins","reason":"resignation"}`,
+		`"kingCapture":"4-at-throne"`,
+		`"escape":"corner"`,
+		`"repetition":"draw"`,
+		`"hostileThrone":true`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s does not contain %s", data, want)
+		}
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Rules != rules.BrandubhRules || s.Outcome != p.Outcome() {
+		t.Errorf("read back %+v and %+v", s.Rules, s.Outcome)
+	}
+
+	q, err := s.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Outcome() != p.Outcome() {
+		t.Errorf("replayed outcome %v, want %v", q.Outcome(), p.Outcome())
+	}
+
+	if err := json.Unmarshal([]byte(`{"result":"won","reason":""}`), &s.Outcome); err == nil {
+		t.Error("unknown result read without error")
+	}
+}
+
+func TestSpectators(t *testing.T) {
+	addr := listen(t, &Server{Variant: rules.Brandubh})
+	black, white := pair(t, addr)
+
+	// play alternates the moves between black and white, starting with
+	// whoever is to move, and lets everyone watching see each one.
+	players := []*Client{black, white}
+	var watching []*Client
+
+	play := func(moves ...string) *State {
+		t.Helper()
+
+		var s *State
+
+		for _, m := range moves {
+			players[0].write(Message{Type: TypeMove, Move: m})
+
+			for _, c := range append(players, watching...) {
+				s = expect(t, c, TypeState).State
+			}
+
+			players[0], players[1] = players[1], players[0]
+		}
+
+		return s
+	}
+
+	play("a4-a5", "e4-e5", "a5-c5")
+
+	spectator, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { spectator.Close() })
+
+	lobby := expect(t, spectator, TypeLobby)
+	if len(lobby.Games) != 1 || lobby.Games[0].Variant != "brandubh" {
+		t.Fatalf("got games %+v", lobby.Games)
+	}
+
+	// Joining late brings the whole game so far.
+
+	spectator.write(Message{Type: TypeWatch, Room: lobby.Games[0].ID})
+	if m := expect(t, spectator, TypeWatching); m.Room != lobby.Games[0].ID || m.Variant != "brandubh" {
+		t.Errorf("got %+v", m)
+	}
+
+	snapshot := expect(t, spectator, TypeState).State
+	if len(snapshot.Moves) != 3 || snapshot.Last != "a5-c5" || snapshot.Board[2] != "..bww.." || snapshot.Spectators != 1 {
+		t.Fatalf("got %+v", snapshot)
+	}
+
+	// The players are told about the spectator too.
+	expect(t, black, TypeState)
+	expect(t, white, TypeState)
+
+	watching = append(watching, spectator)
+
+	if s := play("e5-e4", "b4-b3", "e4-e5", "b3-c3"); len(s.Captured) != 1 || s.Captured[0] != "c4" {
+		t.Errorf("got captures %v", s.Captured)
+	}
+
+	spectator.write(Message{Type: TypeMove, Move: "c5-c6"})
+	if m := expect(t, spectator, TypeError); m.Error != ErrNotPlaying.Error() {
+		t.Errorf("got %v", m.Error)
+	}
+
+	spectator.write(Message{Type: TypeWatch, Room: "NOSUCH"})
+	expect(t, spectator, TypeError)
+
+	white.Resign()
+	expect(t, spectator, TypeState)
+
+	// Finished games leave the lobby.
+	for len(lobby.Games) != 0 {
+		lobby = expect(t, spectator, TypeLobby)
+	}
+}
+
+func TestSpectatorThatNeverReads(t *testing.T) {
+	s := &Server{Variant: rules.Brandubh}
+	addr := listen(t, s)
+	black, white := pair(t, addr)
+
+	observer, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { observer.Close() })
+
+	id := expect(t, observer, TypeLobby).Games[0].ID
+
+	// The spectator asks to watch and then never reads a thing.
+	server, stuck := net.Pipe()
+	t.Cleanup(func() { stuck.Close() })
+
+	go s.ServeConn(server)
+
+	if err := json.NewEncoder(stuck).Encode(Message{Type: TypeWatch, Room: id}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*Client{black, white} {
+		if st := expect(t, c, TypeState).State; st.Spectators != 1 {
+			t.Fatalf("got %v spectators", st.Spectators)
+		}
+	}
+
+	// The game, the lobby and new clients carry on regardless.
+	black.write(Message{Type: TypeMove, Move: "a4-a5"})
+	expect(t, black, TypeState)
+	expect(t, white, TypeState)
+
+	white.Resign()
+	expect(t, black, TypeState)
+
+	black.Again()
+	expect(t, black, TypeWait)
+
+	late := dial(t, addr)
+
+	// Once too much has piled up for it, the spectator is dropped.
+	closed := make(chan struct{})
+
+	for i := 0; i < outboxSize; i++ {
+		late.Create("", "", "", false)
+		expect(t, late, TypeRoom)
+		late.Cancel()
+	}
+
+	go func() {
+		io.Copy(io.Discard, stuck)
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the spectator was never dropped")
+	}
+}
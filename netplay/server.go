@@ -0,0 +1,395 @@
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+var (
+	ErrNotPlaying  = errors.New("netplay: not in a game")
+	ErrNotYourTurn = errors.New("netplay: not your turn")
+	ErrGameOver    = errors.New("netplay: the game is over")
+	ErrGameGoingOn = errors.New("netplay: the game is still going on")
+	ErrNoRoom      = errors.New("netplay: no such room")
+	ErrNoGame      = errors.New("netplay: no such game")
+	ErrOwnRoom     = errors.New("netplay: cannot join your own room")
+)
+
+// DefaultGrace is how long a player whose connection drops has to come
+// back, unless the server says otherwise.
+const DefaultGrace = time.Minute
+
+// The server queues up to outboxSize messages for each client. A client
+// that falls further behind, or takes longer than writeTimeout to take a
+// message, is dropped.
+const (
+	outboxSize   = 64
+	writeTimeout = 10 * time.Second
+)
+
+// Server keeps a lobby of open rooms and a quick-match queue, and referees
+// the games they lead to in front of any number of spectators.
+type Server struct {
+	// Variant is played when a client does not ask for one; nil means
+	// Copenhagen.
+	Variant *rules.Variant
+
+	// AdjustRules, when set, changes the rules of every new game.
+	AdjustRules func(*rules.RuleSet)
+
+	// Grace is how long a player whose connection drops has to resume the
+	// game before losing it by abandonment. Zero means DefaultGrace.
+	Grace time.Duration
+
+	mu      sync.Mutex
+	clients map[*client]bool
+	rooms   map[string]*room
+	queue   []*seek
+
+	// tables holds the games going on, by the ID of the room they were
+	// played in or a new one for quick matches.
+	tables map[string]*table
+}
+
+type client struct {
+	*conn
+
+	// out holds the messages waiting to be written by writeLoop, so that a
+	// client that stops reading holds up nobody else. done is closed once
+	// the client is gone.
+	out     chan Message
+	done    chan struct{}
+	dropped sync.Once
+
+	// The rest is guarded by Server.mu. table is the client's latest
+	// game, which may be over.
+	table    *table
+	side     rules.Side
+	room     *room
+	seek     *seek
+	watching *table
+}
+
+// Serve accepts connections on l until it fails, serving each one in a
+// goroutine of its own.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.ServeConn(c)
+	}
+}
+
+// ServeConn plays games with the client on rwc until it goes away, and
+// then closes rwc.
+func (s *Server) ServeConn(rwc io.ReadWriteCloser) {
+	c := &client{conn: newConn(rwc), out: make(chan Message, outboxSize), done: make(chan struct{})}
+	defer rwc.Close()
+	defer close(c.done)
+
+	go c.writeLoop()
+
+	s.mu.Lock()
+	if s.clients == nil {
+		s.clients = make(map[*client]bool)
+	}
+
+	s.clients[c] = true
+	lobby := s.lobby()
+	s.mu.Unlock()
+
+	defer s.leave(c)
+
+	c.send(lobby)
+
+	for {
+		m, err := c.read()
+		if _, ok := err.(badMessage); !ok && err != nil {
+			return
+		}
+
+		if err == nil {
+			err = s.handle(c, m)
+		}
+
+		if err != nil {
+			c.send(Message{Type: TypeError, Error: err.Error()})
+		}
+	}
+}
+
+// send queues m for the client without waiting, dropping the client if
+// too much is queued already.
+func (c *client) send(m Message) {
+	select {
+	case c.out <- m:
+	default:
+		c.drop()
+	}
+}
+
+// drop closes the client's connection, which ends ServeConn.
+func (c *client) drop() {
+	c.dropped.Do(func() { c.rwc.Close() })
+}
+
+// writeLoop writes the queued messages until the client is gone, giving
+// each one writeTimeout when the connection has deadlines.
+func (c *client) writeLoop() {
+	deadline, _ := c.rwc.(interface{ SetWriteDeadline(time.Time) error })
+
+	for {
+		select {
+		case m := <-c.out:
+			if deadline != nil {
+				deadline.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+
+			if err := c.write(m); err != nil {
+				c.drop()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (s *Server) handle(c *client, m Message) error {
+	s.mu.Lock()
+	t, side := c.table, c.side
+	s.mu.Unlock()
+
+	switch m.Type {
+	case TypeCreate:
+		return s.create(c, m)
+	case TypeJoin:
+		return s.join(c, m.Room)
+	case TypeQuick:
+		v, tc, err := s.settings(m.Variant, m.Clock)
+		if err != nil {
+			return err
+		}
+
+		return s.quick(c, v, tc)
+	case TypeWatch:
+		return s.watch(c, m.Room)
+	case TypeCancel:
+		s.mu.Lock()
+		changed := s.cancel(c)
+		s.mu.Unlock()
+
+		if changed {
+			s.broadcastLobby()
+		}
+	case TypeMove:
+		if t == nil {
+			return ErrNotPlaying
+		}
+
+		return t.move(side, m.Move)
+	case TypeResign:
+		if t == nil {
+			return ErrNotPlaying
+		}
+
+		return t.resign(side)
+	case TypeResume:
+		return s.resume(c, m.Session)
+	case TypeAgain:
+		if t == nil {
+			return ErrNotPlaying
+		}
+
+		return s.quick(c, t.position.Variant, t.clock)
+	default:
+		return fmt.Errorf("netplay: unknown message type %q", m.Type)
+	}
+
+	return nil
+}
+
+func (s *Server) variant() *rules.Variant {
+	if s.Variant == nil {
+		return rules.Copenhagen
+	}
+
+	return s.Variant
+}
+
+// settings reads the variant and time control a client asked for.
+func (s *Server) settings(variant, clock string) (*rules.Variant, TimeControl, error) {
+	v := s.variant()
+
+	if variant != "" {
+		var ok bool
+		if v, ok = rules.VariantByID(variant); !ok {
+			return nil, TimeControl{}, fmt.Errorf("netplay: unknown variant %q", variant)
+		}
+	}
+
+	tc, err := ParseTimeControl(clock)
+
+	return v, tc, err
+}
+
+// newTable seats black and white at a new game called id. s.mu must be
+// held.
+func (s *Server) newTable(id string, private bool, v *rules.Variant, tc TimeControl, black, white *client) *table {
+	t := &table{
+		id:       id,
+		private:  private,
+		ended:    s.endTable,
+		position: rules.NewPosition(v),
+		clock:    tc,
+		players:  [2]*client{black, white},
+		sessions: [2]string{newSession(), newSession()},
+		grace:    s.Grace,
+	}
+
+	if t.grace == 0 {
+		t.grace = DefaultGrace
+	}
+
+	if s.AdjustRules != nil {
+		s.AdjustRules(&t.position.Rules)
+	}
+
+	for side, c := range t.players {
+		c.table, c.side = t, rules.Side(side)
+	}
+
+	if s.tables == nil {
+		s.tables = make(map[string]*table)
+	}
+
+	s.tables[id] = t
+
+	return t
+}
+
+// endTable takes a finished game out of the lobby. Its spectators stay
+// until they leave.
+func (s *Server) endTable(t *table) {
+	s.mu.Lock()
+	delete(s.tables, t.id)
+	s.mu.Unlock()
+
+	if !t.private {
+		s.broadcastLobby()
+	}
+}
+
+// watch makes c a spectator of the game called id.
+func (s *Server) watch(c *client, id string) error {
+	s.mu.Lock()
+
+	t, ok := s.tables[id]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNoGame
+	}
+
+	changed, err := s.free(c)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	c.watching = t
+	s.mu.Unlock()
+
+	if changed {
+		s.broadcastLobby()
+	}
+
+	t.watch(c)
+
+	return nil
+}
+
+// resume seats c in the game whose session it was given, in place of the
+// connection that dropped, and tells it how the game stands.
+func (s *Server) resume(c *client, session string) error {
+	s.mu.Lock()
+
+	t, side, ok := s.seat(session)
+	if !ok {
+		s.mu.Unlock()
+		return ErrNoGame
+	}
+
+	changed, err := s.free(c)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	c.table, c.side = t, side
+	s.mu.Unlock()
+
+	// The old connection may not have noticed it dropped yet; it is of no
+	// use to anyone now.
+	if old := t.rejoin(side, c); old != c {
+		s.mu.Lock()
+		if old.table == t {
+			old.table = nil
+		}
+		s.mu.Unlock()
+
+		old.drop()
+	}
+
+	if changed {
+		s.broadcastLobby()
+	}
+
+	return nil
+}
+
+// seat finds the game and side session was given for. s.mu must be held.
+func (s *Server) seat(session string) (*table, rules.Side, bool) {
+	if session == "" {
+		return nil, rules.Black, false
+	}
+
+	for _, t := range s.tables {
+		for side, ss := range t.sessions {
+			if ss == session {
+				return t, rules.Side(side), true
+			}
+		}
+	}
+
+	return nil, rules.Black, false
+}
+
+// leave closes the room of a client that went away. Its game waits for it
+// to resume before it is lost.
+func (s *Server) leave(c *client) {
+	s.mu.Lock()
+
+	delete(s.clients, c)
+	changed := s.cancel(c)
+
+	t, side := c.table, c.side
+	c.table = nil
+	s.mu.Unlock()
+
+	if changed {
+		s.broadcastLobby()
+	}
+
+	if t != nil {
+		t.drop(side, c)
+	}
+}
@@ -0,0 +1,294 @@
+package netplay
+
+import (
+	"sync"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// table is a game, the clients playing it and those watching. Messages to
+// them are only queued while t.mu is held; nothing waits on the network
+// with it locked.
+type table struct {
+	id      string
+	private bool
+
+	// ended is called once, in a goroutine of its own, when the game is
+	// over.
+	ended func(*table)
+
+	mu         sync.Mutex
+	position   rules.Position
+	moves      []rules.Move
+	captured   []string
+	players    [2]*client
+	spectators map[*client]bool
+	over       bool
+
+	// sessions are the secrets the players resume the game with, and away
+	// holds a timer for each player whose connection dropped, which
+	// forfeits the game once grace runs out.
+	sessions [2]string
+	away     [2]*time.Timer
+	grace    time.Duration
+
+	clock     TimeControl
+	remaining [2]time.Duration
+	turnStart time.Time
+	timer     *time.Timer
+}
+
+func (t *table) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for side, c := range t.players {
+		c.send(Message{Type: TypeStart, Side: sideName(rules.Side(side)), Session: t.sessions[side]})
+	}
+
+	t.remaining = [2]time.Duration{t.clock.Base, t.clock.Base}
+	t.startClock()
+	t.broadcast()
+}
+
+// startClock starts the time of the side to move running. t.mu must be
+// held.
+func (t *table) startClock() {
+	if !t.clock.IsTimed() || t.position.Outcome().IsOver() {
+		return
+	}
+
+	side, count := t.position.ToMove(), t.position.MoveCount
+	t.turnStart = time.Now()
+	t.timer = time.AfterFunc(t.remaining[side], func() { t.flag(side, count) })
+}
+
+// stopClock charges the side to move for the time it took, ending the
+// game if that was more than it had. t.mu must be held.
+func (t *table) stopClock() {
+	if !t.clock.IsTimed() || t.position.Outcome().IsOver() {
+		return
+	}
+
+	t.timer.Stop()
+
+	side := t.position.ToMove()
+	if t.remaining[side] -= time.Since(t.turnStart); t.remaining[side] <= 0 {
+		t.remaining[side] = 0
+		t.position.Timeout(side)
+	}
+}
+
+// flag ends the game when side runs out of time thinking about move count.
+func (t *table) flag(side rules.Side, count int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.position.MoveCount != count || t.position.Outcome().IsOver() {
+		return
+	}
+
+	t.remaining[side] = 0
+	t.position.Timeout(side)
+	t.broadcast()
+}
+
+func (t *table) state() *State {
+	s := NewState(&t.position, t.moves)
+	s.Captured = t.captured
+	s.Spectators = len(t.spectators)
+
+	for side, timer := range t.away {
+		if timer != nil {
+			s.Away = append(s.Away, sideName(rules.Side(side)))
+		}
+	}
+	s.Clock = t.clock.String()
+
+	if len(s.Moves) > 0 {
+		s.Last = s.Moves[len(s.Moves)-1]
+	}
+
+	if t.clock.IsTimed() {
+		remaining := t.remaining
+
+		if !t.position.Outcome().IsOver() {
+			remaining[t.position.ToMove()] -= time.Since(t.turnStart)
+		}
+
+		for _, d := range remaining {
+			s.Remaining = append(s.Remaining, max(d, 0).Milliseconds())
+		}
+	}
+
+	return s
+}
+
+// broadcast sends the state of the game to its players and spectators.
+// t.mu must be held.
+func (t *table) broadcast() {
+	m := Message{Type: TypeState, State: t.state()}
+
+	for side, c := range t.players {
+		if t.away[side] == nil {
+			c.send(m)
+		}
+	}
+
+	for c := range t.spectators {
+		c.send(m)
+	}
+
+	if t.position.Outcome().IsOver() && !t.over {
+		t.over = true
+
+		if t.ended != nil {
+			go t.ended(t)
+		}
+	}
+}
+
+// watch adds a spectator, who is sent the game so far.
+func (t *table) watch(c *client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.spectators == nil {
+		t.spectators = make(map[*client]bool)
+	}
+
+	t.spectators[c] = true
+
+	c.send(Message{Type: TypeWatching, Room: t.id, Variant: t.position.Variant.ID, Clock: t.clock.String()})
+	t.broadcast()
+}
+
+func (t *table) unwatch(c *client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.spectators, c)
+}
+
+// drop gives side, whose connection c went away, until grace runs out to
+// resume the game.
+func (t *table) drop(side rules.Side, c *client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.players[side] != c || t.position.Outcome().IsOver() {
+		return
+	}
+
+	t.away[side] = time.AfterFunc(t.grace, func() { t.abandon(side, c) })
+	t.broadcast()
+}
+
+// abandon ends the game against side if c, whose connection dropped, has
+// not been replaced by then.
+func (t *table) abandon(side rules.Side, c *client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.players[side] != c || t.position.Outcome().IsOver() {
+		return
+	}
+
+	t.stopClock()
+	t.away[side] = nil
+	t.position.Abandon(side)
+	t.broadcast()
+}
+
+// rejoin seats c as side, sends it the game, and returns the client it
+// replaced.
+func (t *table) rejoin(side rules.Side, c *client) *client {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old := t.players[side]
+	t.players[side] = c
+
+	if t.away[side] != nil {
+		t.away[side].Stop()
+		t.away[side] = nil
+	}
+
+	c.send(Message{Type: TypeStart, Side: sideName(side), Session: t.sessions[side]})
+	t.broadcast()
+
+	return old
+}
+
+func (t *table) isOver() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.position.Outcome().IsOver()
+}
+
+func (t *table) move(side rules.Side, s string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch {
+	case t.position.Outcome().IsOver():
+		return ErrGameOver
+	case t.position.ToMove() != side:
+		return ErrNotYourTurn
+	}
+
+	m, err := rules.ParseMove(s, t.position.Size())
+	if err != nil {
+		return err
+	}
+
+	if !t.position.IsLegal(m) {
+		return rules.ErrIllegalMove
+	}
+
+	if t.stopClock(); t.position.Outcome().IsOver() {
+		t.broadcast()
+		return ErrGameOver
+	}
+
+	before := t.position
+	t.position.Apply(m)
+
+	// Every square emptied by the move, apart from the one it left, lost
+	// its piece to a capture.
+	t.captured = nil
+	for row := 0; row < t.position.Size(); row++ {
+		for col := 0; col < t.position.Size(); col++ {
+			c := rules.Coord{Row: row, Col: col}
+
+			if c != m.From && before.HasPiece(c) && !t.position.HasPiece(c) {
+				t.captured = append(t.captured, rules.FormatCoord(c, t.position.Size()))
+			}
+		}
+	}
+
+	t.moves = append(t.moves, m)
+	t.remaining[side] += t.clock.Increment
+
+	t.startClock()
+	t.broadcast()
+
+	return nil
+}
+
+func (t *table) resign(side rules.Side) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.position.Outcome().IsOver() {
+		return ErrGameOver
+	}
+
+	t.stopClock()
+	t.position.Resign(side)
+	t.broadcast()
+
+	return nil
+}
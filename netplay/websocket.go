@@ -0,0 +1,61 @@
+package netplay
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	websocket "github.com/technologyfreak/hnefatafl/websocket"
+)
+
+// ServeWebSocket plays games with a browser, sending each message in a
+// websocket message of its own instead of a line.
+func (s *Server) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	c, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+
+	s.ServeConn(&wsStream{conn: c})
+}
+
+// wsStream turns websocket messages into lines and back.
+type wsStream struct {
+	conn    *websocket.Conn
+	pending []byte
+}
+
+func (ws *wsStream) Read(p []byte) (int, error) {
+	if len(ws.pending) == 0 {
+		m, err := ws.conn.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+
+		ws.pending = append(bytes.TrimRight(m, "\n"), '\n')
+	}
+
+	n := copy(p, ws.pending)
+	ws.pending = ws.pending[n:]
+
+	return n, nil
+}
+
+// Write sends p, which holds whole lines, one websocket message per line.
+func (ws *wsStream) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if err := ws.conn.WriteMessage(line); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (ws *wsStream) SetWriteDeadline(t time.Time) error {
+	return ws.conn.SetWriteDeadline(t)
+}
+
+func (ws *wsStream) Close() error {
+	return ws.conn.Close()
+}
@@ -0,0 +1,167 @@
+package otep
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+const handshakeTimeout = 10 * time.Second
+
+var ErrEngineExited = errors.New("otep: engine exited")
+
+// Client drives an external OTEP engine running as a subprocess. It is a
+// player.Player, so the engine can take a side in a game.
+type Client struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string
+
+	mu      sync.Mutex
+	variant *rules.Variant
+	rules   rules.RuleSet
+
+	// stale counts moves still owed by searches that were cancelled.
+	stale int
+}
+
+// Start runs the engine and waits for it to answer hello.
+func Start(name string, args ...string) (*Client, error) {
+	cmd := exec.Command(name, args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := &Client{cmd: cmd, stdin: stdin, lines: make(chan string, 64)}
+	go c.read(stdout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+
+	if err := c.send("hello"); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	if _, err := c.await(ctx, "hello"); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("otep: %v did not say hello: %w", name, err)
+	}
+
+	return c, nil
+}
+
+func (c *Client) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		c.lines <- strings.TrimSpace(scanner.Text())
+	}
+
+	close(c.lines)
+}
+
+func (c *Client) send(line string) error {
+	_, err := fmt.Fprintln(c.stdin, line)
+
+	return err
+}
+
+// await skips lines until one starts with command, and returns the rest
+// of it.
+func (c *Client) await(ctx context.Context, command string) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case line, ok := <-c.lines:
+			if !ok {
+				return "", ErrEngineExited
+			}
+
+			got, args, _ := strings.Cut(line, " ")
+
+			switch got {
+			case command:
+				return args, nil
+			case "error":
+				return "", fmt.Errorf("otep: engine error: %v", args)
+			}
+		}
+	}
+}
+
+func (c *Client) NextMove(ctx context.Context, p rules.Position) (rules.Move, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for ; c.stale > 0; c.stale-- {
+		if _, err := c.await(ctx, "move"); err != nil {
+			return rules.Move{}, err
+		}
+	}
+
+	if p.Variant != c.variant || p.Rules != c.rules {
+		if err := c.send("rules " + FormatRules(p.Variant, p.Rules)); err != nil {
+			return rules.Move{}, err
+		}
+
+		c.variant, c.rules = p.Variant, p.Rules
+	}
+
+	if err := c.send("position " + FormatPosition(&p)); err != nil {
+		return rules.Move{}, err
+	}
+
+	if err := c.send("play " + sideName(p.ToMove())); err != nil {
+		return rules.Move{}, err
+	}
+
+	move, err := c.await(ctx, "move")
+	if err != nil {
+		if ctx.Err() != nil {
+			c.stale++
+		}
+
+		return rules.Move{}, err
+	}
+
+	return ParseMove(move, p.Size())
+}
+
+// Close says goodbye and waits a moment for the engine to leave before
+// killing it.
+func (c *Client) Close() error {
+	c.send("goodbye")
+	c.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- c.cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		c.cmd.Process.Kill()
+		return <-done
+	}
+}
@@ -0,0 +1,225 @@
+package otep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// ParsePosition reads an OpenTafl position such as /3ttttt3/5t5/11/.../,
+// listing the ranks from the top with t for attackers, T for defenders, K
+// for the king and numbers for runs of empty squares. It returns the rows
+// in the b, w, k and . form rules.NewVariant takes.
+func ParsePosition(s string) ([]string, error) {
+	var setup []string
+
+	ranks := strings.Split(strings.Trim(strings.TrimSpace(s), "/"), "/")
+	size := len(ranks)
+
+	if size > rules.MaxSquaresPerRow {
+		return nil, fmt.Errorf("otep: position %q is larger than %vx%v", s, rules.MaxSquaresPerRow, rules.MaxSquaresPerRow)
+	}
+
+	for _, rank := range ranks {
+		var row strings.Builder
+		empty := 0
+
+		for _, r := range rank {
+			if r >= '0' && r <= '9' {
+				// Stop a long run of digits before it grows past the board.
+				if empty = empty*10 + int(r-'0'); row.Len()+empty > size {
+					return nil, fmt.Errorf("otep: position %q is not square", s)
+				}
+
+				continue
+			}
+
+			row.WriteString(strings.Repeat(".", empty))
+			empty = 0
+
+			switch r {
+			case 't':
+				row.WriteByte('b')
+			case 'T':
+				row.WriteByte('w')
+			case 'K', 'k':
+				row.WriteByte('k')
+			default:
+				return nil, fmt.Errorf("otep: unknown piece %q in position %q", r, s)
+			}
+		}
+
+		row.WriteString(strings.Repeat(".", empty))
+		setup = append(setup, row.String())
+	}
+
+	for _, row := range setup {
+		if len(row) != len(setup) {
+			return nil, fmt.Errorf("otep: position %q is not square", s)
+		}
+	}
+
+	return setup, nil
+}
+
+// FormatPosition writes p the way ParsePosition reads it.
+func FormatPosition(p *rules.Position) string {
+	var b strings.Builder
+	b.WriteByte('/')
+
+	for row := 0; row < p.Size(); row++ {
+		empty := 0
+
+		for col := 0; col < p.Size(); col++ {
+			c := rules.Coord{Row: row, Col: col}
+
+			if !p.HasPiece(c) {
+				empty++
+				continue
+			}
+
+			if empty > 0 {
+				b.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+
+			switch {
+			case p.IsBlack(c):
+				b.WriteByte('t')
+			case p.IsKing(c):
+				b.WriteByte('K')
+			default:
+				b.WriteByte('T')
+			}
+		}
+
+		if empty > 0 {
+			b.WriteString(strconv.Itoa(empty))
+		}
+
+		b.WriteByte('/')
+	}
+
+	return b.String()
+}
+
+// ParseMove reads a move such as a4-a7. A leading piece letter and any
+// captures written after an x are ignored.
+func ParseMove(s string, size int) (rules.Move, error) {
+	s = strings.TrimLeft(strings.TrimSpace(s), "tTK")
+
+	if i := strings.IndexByte(s, 'x'); i >= 0 {
+		s = s[:i]
+	}
+
+	return rules.ParseMove(s, size)
+}
+
+func sideName(s rules.Side) string {
+	if s == rules.Black {
+		return "attackers"
+	}
+
+	return "defenders"
+}
+
+func parseSide(s string) (rules.Side, error) {
+	switch s {
+	case "attackers":
+		return rules.Black, nil
+	case "defenders":
+		return rules.White, nil
+	}
+
+	return rules.Black, fmt.Errorf("otep: unknown side %q", s)
+}
+
+// FormatRules describes a game of v played under r to another engine:
+// its size, name, starting position and every one of the rules.
+func FormatRules(v *rules.Variant, r rules.RuleSet) string {
+	p := rules.NewPosition(v)
+
+	return fmt.Sprintf("dim:%d name:%s start:%s armed-king:%t king-capture:%v escape:%v hostile-throne:%t pass-throne:%t shieldwall:%t edge-forts:%t repetition:%v move-limit:%d",
+		v.Size, strings.ReplaceAll(v.Name, " ", "_"), FormatPosition(&p),
+		r.KingArmed, r.KingCapture, r.Escape, r.EmptyThroneHostileToDefenders, r.PassThroughThrone,
+		r.Shieldwall, r.EdgeForts, r.Repetition, r.MoveLimit)
+}
+
+// parseRules reads the variant a rules string from FormatRules describes.
+// Rules it leaves out are those of the built-in variant with the same
+// starting position, or Copenhagen's; keys it does not know, or gives
+// twice, are refused.
+func parseRules(s string) (*rules.Variant, error) {
+	fields := map[string]string{}
+
+	for _, field := range strings.Fields(s) {
+		key, value, _ := strings.Cut(field, ":")
+
+		if _, ok := fields[key]; ok {
+			return nil, fmt.Errorf("otep: rules %q give %v twice", s, key)
+		}
+
+		fields[key] = value
+	}
+
+	start, ok := fields["start"]
+	if !ok {
+		return nil, fmt.Errorf("otep: rules %q have no starting position", s)
+	}
+
+	setup, err := ParsePosition(start)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ReplaceAll(fields["name"], "_", " ")
+	r := rules.CopenhagenRules
+
+	for _, v := range rules.Variants {
+		if strings.Join(v.Setup, "/") == strings.Join(setup, "/") {
+			r = v.Rules
+
+			if name == "" {
+				name = v.Name
+			}
+		}
+	}
+
+	for key, value := range fields {
+		switch key {
+		case "start", "name":
+		case "dim":
+			if value != strconv.Itoa(len(setup)) {
+				err = fmt.Errorf("otep: rules %q give dim %v for a %vx%v board", s, value, len(setup), len(setup))
+			}
+		case "armed-king":
+			r.KingArmed, err = strconv.ParseBool(value)
+		case "king-capture":
+			r.KingCapture, err = rules.ParseKingCapture(value)
+		case "escape":
+			r.Escape, err = rules.ParseEscape(value)
+		case "hostile-throne":
+			r.EmptyThroneHostileToDefenders, err = strconv.ParseBool(value)
+		case "pass-throne":
+			r.PassThroughThrone, err = strconv.ParseBool(value)
+		case "shieldwall":
+			r.Shieldwall, err = strconv.ParseBool(value)
+		case "edge-forts":
+			r.EdgeForts, err = strconv.ParseBool(value)
+		case "repetition":
+			r.Repetition, err = rules.ParseRepetition(value)
+		case "move-limit":
+			r.MoveLimit, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("otep: unknown rule %q", key)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rules.NewVariant("otep", name, r, setup...)
+}
@@ -0,0 +1,289 @@
+package otep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// The client tests run this test binary as the engine.
+func TestMain(m *testing.M) {
+	if os.Getenv("OTEP_TEST_ENGINE") == "1" {
+		if err := Serve(context.Background(), os.Stdin, os.Stdout, testEngine()); err != nil {
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func testEngine() ai.Engine {
+	s := ai.NewSearcher()
+	s.MaxDepth = 2
+	s.Time = 0
+	s.Seed = 1
+
+	return s
+}
+
+func TestPositionRoundTrip(t *testing.T) {
+	for _, v := range rules.Variants {
+		p := rules.NewPosition(v)
+
+		setup, err := ParsePosition(FormatPosition(&p))
+		if err != nil {
+			t.Fatalf("%v: %v", v.ID, err)
+		}
+
+		if strings.Join(setup, "/") != strings.Join(v.Setup, "/") {
+			t.Errorf("%v: got %v, want %v", v.ID, setup, v.Setup)
+		}
+	}
+
+	if got := FormatPosition(&rules.Position{Variant: rules.Brandubh}); got != "/7/7/7/7/7/7/7/" {
+		t.Errorf("empty board written as %v", got)
+	}
+
+	for _, s := range []string{"/3t99999999999999999999999999/7/7/7/7/7/7/", "/8/7/7/7/7/7/7/", "/t7/7/7/7/7/7/7/"} {
+		if _, err := ParsePosition(s); err == nil {
+			t.Errorf("%v: parsed a row that runs off the board", s)
+		}
+	}
+}
+
+func TestRulesRoundTrip(t *testing.T) {
+	for _, v := range rules.Variants {
+		// Every rule is sent, not just the ones that differ from the
+		// variant's.
+		r := v.Rules
+		r.KingArmed, r.Shieldwall, r.MoveLimit = !r.KingArmed, !r.Shieldwall, 150
+
+		for _, want := range []rules.RuleSet{v.Rules, r} {
+			got, err := parseRules(FormatRules(v, want))
+			if err != nil {
+				t.Fatalf("%v: %v", v.ID, err)
+			}
+
+			if got.Name != v.Name || strings.Join(got.Setup, "/") != strings.Join(v.Setup, "/") || got.Rules != want {
+				t.Errorf("%v: got %v %+v, want %+v", v.ID, got.Name, got.Rules, want)
+			}
+		}
+	}
+
+	for _, s := range []string{
+		FormatRules(rules.Brandubh, rules.BrandubhRules) + " tfl:y",
+		FormatRules(rules.Brandubh, rules.BrandubhRules) + " escape:sideways",
+		strings.Replace(FormatRules(rules.Brandubh, rules.BrandubhRules), "dim:7", "dim:9", 1),
+		FormatRules(rules.Brandubh, rules.BrandubhRules) + " shieldwall:true",
+	} {
+		if _, err := parseRules(s); err == nil {
+			t.Errorf("accepted %q", s)
+		}
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	for _, s := range []string{"d7-b7", "td7-b7", "d7-b7xb6", "td7-b7xb6/c7"} {
+		m, err := ParseMove(s, 7)
+		if err != nil {
+			t.Fatalf("%v: %v", s, err)
+		}
+
+		if want := (rules.Move{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 1}}); m != want {
+			t.Errorf("%v: got %v, want %v", s, m, want)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	p := rules.NewPosition(rules.Brandubh)
+	commands := strings.Join([]string{
+		"hello",
+		"rules " + FormatRules(rules.Brandubh, rules.BrandubhRules),
+		"side defenders",
+		"opponent-move d7-b7",
+		"play defenders",
+		"goodbye",
+		"play attackers",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := Serve(context.Background(), strings.NewReader(commands), &out, testEngine()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[0] != "hello" || !strings.HasPrefix(lines[1], "move ") {
+		t.Fatalf("got %q", lines)
+	}
+
+	if err := p.Apply(rules.Move{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ParseMove(strings.TrimPrefix(lines[1], "move "), p.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.IsLegal(m) {
+		t.Errorf("engine played illegal move %v", m)
+	}
+}
+
+// The engine must search for the side it is told to play even when the
+// other side would have no moves.
+func TestServePlaysTheSideToMove(t *testing.T) {
+	v, err := rules.NewVariant("test", "Test", rules.BrandubhRules,
+		".bw....",
+		".w.....",
+		".......",
+		"...k...",
+		".......",
+		".......",
+		".......",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := rules.NewPosition(v)
+	commands := strings.Join([]string{
+		"rules " + FormatRules(rules.Brandubh, rules.BrandubhRules),
+		"position " + FormatPosition(&p),
+		"side defenders",
+		"play",
+		"finish 1",
+		"play attackers",
+	}, "\n")
+
+	var out bytes.Buffer
+	if err := Serve(context.Background(), strings.NewReader(commands), &out, testEngine()); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q", lines)
+	}
+
+	p.SetToMove(rules.White)
+	start := rules.NewPosition(rules.Brandubh)
+
+	for i, q := range []rules.Position{p, start} {
+		m, err := ParseMove(strings.TrimPrefix(lines[i], "move "), q.Size())
+		if err != nil {
+			t.Fatalf("%q: %v", lines[i], err)
+		}
+
+		if !q.IsLegal(m) {
+			t.Errorf("engine played illegal move %v", m)
+		}
+	}
+}
+
+// A host that only sends the opponent's moves relies on the engine keeping
+// track of its own.
+func TestServeFollowsItsOwnMoves(t *testing.T) {
+	in, host := io.Pipe()
+	out, engine := io.Pipe()
+
+	go func() {
+		Serve(context.Background(), in, engine, testEngine())
+		engine.Close()
+	}()
+
+	replies := make(chan string)
+	go func() {
+		defer close(replies)
+
+		for scanner := bufio.NewScanner(out); scanner.Scan(); {
+			replies <- scanner.Text()
+		}
+	}()
+
+	send := func(command string) {
+		t.Helper()
+
+		if _, err := io.WriteString(host, command+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reply := func() string {
+		t.Helper()
+
+		select {
+		case line := <-replies:
+			return line
+		case <-time.After(5 * time.Second):
+			t.Fatal("the engine did not answer")
+		}
+
+		return ""
+	}
+
+	p := rules.NewPosition(rules.Brandubh)
+	send("rules " + FormatRules(rules.Brandubh, rules.BrandubhRules))
+
+	for i := 0; i < 4 && !p.Outcome().IsOver(); i++ {
+		send("play attackers")
+
+		line := reply()
+		m, err := ParseMove(strings.TrimPrefix(line, "move "), p.Size())
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+
+		if err := p.Apply(m); err != nil {
+			t.Fatalf("move %v: engine played %v: %v", i, m, err)
+		}
+
+		if p.Outcome().IsOver() {
+			break
+		}
+
+		answer := p.LegalMoves()[0]
+		send("opponent-move " + rules.FormatMove(answer, p.Size()))
+		p.Apply(answer)
+	}
+
+	send("goodbye")
+
+	if line, ok := <-replies; ok {
+		t.Errorf("got %q, want nothing more", line)
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Setenv("OTEP_TEST_ENGINE", "1")
+
+	c, err := Start(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	p := rules.NewPosition(rules.Tablut)
+
+	for i := 0; i < 4; i++ {
+		m, err := c.NextMove(context.Background(), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.Apply(m); err != nil {
+			t.Fatalf("move %v: %v", m, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package otep
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Serve plays as an OTEP engine, reading the host's commands from r and
+// answering on w until the host says goodbye or r runs out. It understands
+// hello, rules, position, side, play, move, opponent-move, finish and
+// goodbye; anything else is ignored. side says which side the engine
+// plays when play leaves it out, and finish sets the pieces up again for
+// the next game.
+func Serve(ctx context.Context, r io.Reader, w io.Writer, engine ai.Engine) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	variant := rules.Copenhagen
+	p := rules.NewPosition(variant)
+	side := rules.Black
+
+	for scanner.Scan() {
+		command, args, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		args = strings.TrimSpace(args)
+
+		var err error
+
+		switch command {
+		case "hello":
+			_, err = fmt.Fprintln(w, "hello")
+		case "rules":
+			if variant, err = parseRules(args); err == nil {
+				p = rules.NewPosition(variant)
+			}
+		case "position":
+			p, err = setPosition(variant, p, args)
+		case "side":
+			side, err = parseSide(args)
+		case "move", "opponent-move":
+			p, err = playMove(variant, p, args)
+		case "play":
+			turn := side
+			if args != "" {
+				if turn, err = parseSide(args); err != nil {
+					break
+				}
+			}
+
+			p.SetToMove(turn)
+
+			var res ai.Result
+			if res, err = engine.Search(ctx, p); err != nil {
+				break
+			}
+
+			if _, err = fmt.Fprintln(w, "move", rules.FormatMove(res.Move, p.Size())); err == nil {
+				// The host only tells of the opponent's moves from here on.
+				err = p.Apply(res.Move)
+			}
+		case "finish":
+			p = rules.NewPosition(variant)
+		case "goodbye":
+			return nil
+		}
+
+		if err != nil {
+			if _, werr := fmt.Fprintln(w, "error -1", err); werr != nil {
+				return werr
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// setPosition replaces the pieces on the board, keeping the rules and the
+// side to move.
+func setPosition(v *rules.Variant, p rules.Position, s string) (rules.Position, error) {
+	setup, err := ParsePosition(s)
+	if err != nil {
+		return p, err
+	}
+
+	v, err = rules.NewVariant(v.ID, v.Name, v.Rules, setup...)
+	if err != nil {
+		return p, err
+	}
+
+	next := rules.NewPosition(v)
+	next.Rules = p.Rules
+	next.SetToMove(p.ToMove())
+
+	return next, nil
+}
+
+// playMove applies a move, or takes the position that follows it when the
+// host sends one along.
+func playMove(v *rules.Variant, p rules.Position, s string) (rules.Position, error) {
+	move, position, _ := strings.Cut(s, " ")
+
+	if position = strings.TrimSpace(position); position != "" {
+		next, err := setPosition(v, p, position)
+		if err != nil {
+			return p, err
+		}
+
+		next.SetToMove(p.ToMove().Opponent())
+
+		return next, nil
+	}
+
+	m, err := ParseMove(move, p.Size())
+	if err != nil {
+		return p, err
+	}
+
+	err = p.Apply(m)
+
+	return p, err
+}
@@ -0,0 +1,55 @@
+package player
+
+import (
+	"context"
+	"errors"
+
+	ai "github.com/technologyfreak/hnefatafl/ai"
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+var ErrReplayOver = errors.New("player: no more moves in the replay")
+
+// Player chooses the moves for one side of a game.
+type Player interface {
+	NextMove(ctx context.Context, p rules.Position) (rules.Move, error)
+}
+
+// Watcher is implemented by players that need to be told the moves their
+// opponent makes, such as a peer on the other end of a connection.
+type Watcher interface {
+	Watch(m rules.Move) error
+}
+
+// Engine lets the computer play a side.
+type Engine struct {
+	ai.Engine
+}
+
+func NewEngine(e ai.Engine) *Engine {
+	return &Engine{Engine: e}
+}
+
+func (e *Engine) NextMove(ctx context.Context, p rules.Position) (rules.Move, error) {
+	res, err := e.Search(ctx, p)
+
+	return res.Move, err
+}
+
+// Replay plays back the moves of a recorded game. The same Replay can
+// play both sides, as it picks the move by how far the game has gone.
+type Replay struct {
+	Moves []rules.Move
+}
+
+func NewReplay(moves []rules.Move) *Replay {
+	return &Replay{Moves: moves}
+}
+
+func (r *Replay) NextMove(ctx context.Context, p rules.Position) (rules.Move, error) {
+	if p.MoveCount >= len(r.Moves) {
+		return rules.Move{}, ErrReplayOver
+	}
+
+	return r.Moves[p.MoveCount], nil
+}
@@ -0,0 +1,74 @@
+package player
+
+import (
+	"context"
+	"testing"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+func TestReplay(t *testing.T) {
+	p := rules.NewPosition(rules.Brandubh)
+	moves := []rules.Move{
+		{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 1}},
+		{From: rules.Coord{Row: 2, Col: 3}, To: rules.Coord{Row: 2, Col: 0}},
+	}
+	r := NewReplay(moves)
+
+	for _, want := range moves {
+		m, err := r.NextMove(context.Background(), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m != want {
+			t.Fatalf("got %v, want %v", m, want)
+		}
+
+		if err := p.Apply(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := r.NextMove(context.Background(), p); err != ErrReplayOver {
+		t.Fatalf("got %v, want %v", err, ErrReplayOver)
+	}
+}
+
+func TestRemote(t *testing.T) {
+	var sent []rules.Move
+
+	r := NewRemote(func(m rules.Move) error {
+		sent = append(sent, m)
+		return nil
+	})
+	p := rules.NewPosition(rules.Brandubh)
+
+	want := rules.Move{From: rules.Coord{Row: 0, Col: 3}, To: rules.Coord{Row: 0, Col: 1}}
+	r.Played(want)
+
+	m, err := r.NextMove(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m != want {
+		t.Fatalf("got %v, want %v", m, want)
+	}
+
+	reply := rules.Move{From: rules.Coord{Row: 2, Col: 3}, To: rules.Coord{Row: 2, Col: 0}}
+	if err := r.Watch(reply); err != nil || len(sent) != 1 || sent[0] != reply {
+		t.Fatalf("sent %v, %v", sent, err)
+	}
+
+	if err := NewRemote(nil).Watch(reply); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := r.NextMove(ctx, p); err != context.Canceled {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+}
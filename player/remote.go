@@ -0,0 +1,48 @@
+package player
+
+import (
+	"context"
+
+	rules "github.com/technologyfreak/hnefatafl/rules"
+)
+
+// Remote is a peer playing from the other end of a connection. Whatever
+// reads the connection hands over the peer's moves with Played, and the
+// moves played against it go out through send.
+type Remote struct {
+	moves chan rules.Move
+	send  func(rules.Move) error
+}
+
+// NewRemote returns a peer that is sent moves with send, or that is only
+// watched when send is nil.
+func NewRemote(send func(rules.Move) error) *Remote {
+	return &Remote{moves: make(chan rules.Move, 1), send: send}
+}
+
+func (r *Remote) NextMove(ctx context.Context, p rules.Position) (rules.Move, error) {
+	select {
+	case <-ctx.Done():
+		return rules.Move{}, ctx.Err()
+	case move := <-r.moves:
+		return move, nil
+	}
+}
+
+// Played hands over a move the peer made; it is dropped if one is already
+// waiting.
+func (r *Remote) Played(move rules.Move) {
+	select {
+	case r.moves <- move:
+	default:
+	}
+}
+
+// Watch sends the peer the move played against it.
+func (r *Remote) Watch(m rules.Move) error {
+	if r.send == nil {
+		return nil
+	}
+
+	return r.send(m)
+}
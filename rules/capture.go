@@ -0,0 +1,253 @@
+package rules
+
+import (
+	board "github.com/technologyfreak/hnefatafl/board"
+	piece "github.com/technologyfreak/hnefatafl/piece"
+)
+
+var (
+	West  = Coord{Row: 0, Col: -1}
+	East  = Coord{Row: 0, Col: 1}
+	North = Coord{Row: -1, Col: 0}
+	South = Coord{Row: 1, Col: 0}
+
+	directions = [...]Coord{West, East, North, South}
+)
+
+func (p *Position) areOpposed(a Coord, b Coord) bool {
+	return p.HasPiece(a) && p.HasPiece(b) && p.IsBlack(a) != p.IsBlack(b)
+}
+
+// The empty throne is always hostile to black and to white when the rules
+// say so; the king is only ever hemmed in by it.
+func (p *Position) throneIsHostileTo(c Coord) bool {
+	return p.IsBlack(c) || (p.Rules.EmptyThroneHostileToDefenders && !p.IsKing(c))
+}
+
+// IsHostileTo reports whether square c can serve as the far side of a
+// custodian capture of the piece on victim. The board edge never is.
+func (p *Position) IsHostileTo(c Coord, victim Coord) bool {
+	if !p.InBounds(c) {
+		return false
+	}
+
+	if p.HasPiece(c) {
+		if !p.areOpposed(c, victim) {
+			return false
+		}
+
+		// An occupied throne still stands against black.
+		return !p.IsKing(c) || p.Rules.KingArmed || p.IsThrone(c)
+	}
+
+	if p.IsKingsCorner(c) {
+		return true
+	}
+
+	return p.IsThrone(c) && p.throneIsHostileTo(victim)
+}
+
+// canCapture reports whether the piece on c may take part in a capture.
+func (p *Position) canCapture(c Coord) bool {
+	return p.HasPiece(c) && (!p.IsKing(c) || p.Rules.KingArmed)
+}
+
+func (p *Position) kingNeedsFourSides(c Coord) bool {
+	switch p.Rules.KingCapture {
+	case KingCapturedOnTwoSides:
+		return false
+	case KingCapturedOnFourSidesAtThrone:
+		if p.IsThrone(c) {
+			return true
+		}
+
+		for _, d := range directions {
+			if p.IsThrone(c.Add(d)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// The king falls when every side is a black pawn, the throne or a corner.
+func (p *Position) kingIsSurrounded(c Coord) bool {
+	for _, d := range directions {
+		n := c.Add(d)
+
+		if !p.InBounds(n) {
+			return false
+		}
+
+		if p.HasPiece(n) {
+			if !p.IsBlack(n) {
+				return false
+			}
+		} else if !p.IsThrone(n) && !p.IsKingsCorner(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hostileToPawns is every square that can serve as the far side of a
+// custodian capture of the black pawns, or of the white ones. It agrees
+// with IsHostileTo.
+func (p *Position) hostileToPawns(black bool) board.Bitboard {
+	g := p.geometry()
+	empty := g.All.AndNot(p.layout.Occupied())
+	hostile := p.Variant.corners.And(empty)
+
+	if black || p.Rules.EmptyThroneHostileToDefenders {
+		hostile = hostile.Or(p.Variant.throne.And(empty))
+	}
+
+	if !black {
+		return hostile.Or(p.layout.Black)
+	}
+
+	white := p.layout.White
+	if !p.Rules.KingArmed {
+		// An occupied throne still stands against black.
+		white = white.AndNot(p.layout.King.AndNot(p.Variant.throne))
+	}
+
+	return hostile.Or(white)
+}
+
+// Captures lists the pieces taken by the piece standing on c, assuming it
+// has just moved there.
+func (p *Position) Captures(c Coord) []Coord {
+	var captured []Coord
+
+	if !p.canCapture(c) {
+		return captured
+	}
+
+	black := !p.IsBlack(c)
+	victims := p.layout.White.AndNot(p.layout.King)
+	if black {
+		victims = p.layout.Black
+	}
+
+	for taken := p.geometry().CaptureMask(p.index(c), victims, p.hostileToPawns(black)); !taken.IsEmpty(); {
+		captured = append(captured, p.coord(taken.PopLowest()))
+	}
+
+	for _, d := range directions {
+		victim := c.Add(d)
+
+		if !p.InBounds(victim) || !p.IsKing(victim) || !p.areOpposed(c, victim) {
+			continue
+		}
+
+		if p.kingNeedsFourSides(victim) {
+			if p.kingIsSurrounded(victim) {
+				captured = append(captured, victim)
+			}
+
+			continue
+		}
+
+		if p.IsHostileTo(victim.Add(d), victim) {
+			captured = append(captured, victim)
+		}
+	}
+
+	if p.Rules.Shieldwall {
+		captured = append(captured, p.shieldwallCaptures(c)...)
+	}
+
+	return captured
+}
+
+// inwardDirections lists, for each board edge c lies on, the direction
+// pointing away from that edge.
+func (p *Position) inwardDirections(c Coord) []Coord {
+	var inward []Coord
+	last := p.Size() - 1
+
+	if c.Row == 0 {
+		inward = append(inward, South)
+	}
+
+	if c.Row == last {
+		inward = append(inward, North)
+	}
+
+	if c.Col == 0 {
+		inward = append(inward, East)
+	}
+
+	if c.Col == last {
+		inward = append(inward, West)
+	}
+
+	return inward
+}
+
+// shieldwallCaptures finds rows of two or more enemy pieces running along
+// the edge from c that are bracketed by c and a friendly piece or a corner
+// at the far end, with one of our pieces in front of every member. The
+// king helps form a wall but is never taken by one.
+func (p *Position) shieldwallCaptures(c Coord) []Coord {
+	var captured []Coord
+
+	for _, in := range p.inwardDirections(c) {
+		for _, d := range [...]Coord{{Row: in.Col, Col: in.Row}, {Row: -in.Col, Col: -in.Row}} {
+			var wall []Coord
+
+			n := c.Add(d)
+			for ; p.InBounds(n) && p.areOpposed(c, n); n = n.Add(d) {
+				front := n.Add(in)
+
+				if !p.areOpposed(front, n) || !p.canCapture(front) {
+					wall = nil
+					break
+				}
+
+				wall = append(wall, n)
+			}
+
+			if len(wall) < 2 || !p.InBounds(n) {
+				continue
+			}
+
+			bracketed := p.IsKingsCorner(n) && !p.HasPiece(n)
+			if p.HasPiece(n) && !p.areOpposed(c, n) && p.canCapture(n) {
+				bracketed = true
+			}
+
+			if !bracketed {
+				continue
+			}
+
+			for _, member := range wall {
+				if !p.IsKing(member) {
+					captured = append(captured, member)
+				}
+			}
+		}
+	}
+
+	return captured
+}
+
+func (p *Position) capture(c Coord) {
+	for _, n := range p.Captures(c) {
+		switch {
+		case p.IsKing(n):
+			p.KingCaptured = true
+		case p.IsBlack(n):
+			p.BlackPawns--
+		default:
+			p.WhitePawns--
+		}
+
+		p.set(n, piece.None)
+	}
+}
@@ -0,0 +1,604 @@
+package rules
+
+import (
+	"testing"
+)
+
+var unarmed = RuleSet{KingCapture: KingCapturedOnFourSides}
+
+var armed = RuleSet{KingArmed: true, KingCapture: KingCapturedOnFourSides}
+
+func TestCaptures(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      RuleSet
+		blacksTurn bool
+		setup      []string
+		move       Move
+		captured   []Coord
+	}{
+		{
+			name:       "pawn sandwiched horizontally",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				"b......",
+				".......",
+				"...k...",
+				".......",
+				".wb....",
+				".......",
+			},
+			move:     Move{From: Coord{1, 0}, To: Coord{5, 0}},
+			captured: []Coord{{5, 1}},
+		},
+		{
+			name:       "pawn sandwiched vertically",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				"..w....",
+				"..b....",
+				"....w..",
+				"...k...",
+				".......",
+				".b.....",
+				".......",
+			},
+			move:     Move{From: Coord{2, 4}, To: Coord{2, 2}},
+			captured: []Coord{{1, 2}},
+		},
+		{
+			name:       "moving between two enemies is safe",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".b.b...",
+				".......",
+				"...k...",
+				".......",
+				"..w....",
+				"...b...",
+			},
+			move:     Move{From: Coord{5, 2}, To: Coord{1, 2}},
+			captured: nil,
+		},
+		{
+			name:       "the edge is not hostile",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				"..w....",
+				".....b.",
+				".......",
+				"...k...",
+				".....w.",
+				".......",
+				"..b....",
+			},
+			move:     Move{From: Coord{1, 5}, To: Coord{1, 2}},
+			captured: nil,
+		},
+		{
+			name:       "the edge is not hostile from the side",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				"w......",
+				".......",
+				"...k...",
+				".b.....",
+				".......",
+				"...w...",
+			},
+			move:     Move{From: Coord{4, 1}, To: Coord{1, 1}},
+			captured: nil,
+		},
+		{
+			name:       "only the moving side captures",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".bwb...",
+				".......",
+				"...k...",
+				".......",
+				"b....w.",
+				".......",
+			},
+			move:     Move{From: Coord{5, 0}, To: Coord{4, 0}},
+			captured: nil,
+		},
+		{
+			name:       "the partner must be opposite the mover",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".......",
+				".......",
+				"...kb..",
+				"b...w..",
+				"....b..",
+				"..w....",
+			},
+			move:     Move{From: Coord{4, 0}, To: Coord{4, 3}},
+			captured: nil,
+		},
+		{
+			name:       "friendly pieces are never captured",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".b.b...",
+				".......",
+				"...k...",
+				"..b....",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{4, 2}, To: Coord{1, 2}},
+			captured: nil,
+		},
+		{
+			name:       "three captures at once",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".......",
+				"..b....",
+				"..w....",
+				"bw....b",
+				"..w....",
+				"..b..k.",
+			},
+			move:     Move{From: Coord{4, 6}, To: Coord{4, 2}},
+			captured: []Coord{{3, 2}, {5, 2}, {4, 1}},
+		},
+		{
+			name:       "corner completes a capture of white",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".w.....",
+				".......",
+				"..b....",
+				"...k...",
+				".......",
+				".....w.",
+				"...b...",
+			},
+			move:     Move{From: Coord{2, 2}, To: Coord{0, 2}},
+			captured: []Coord{{0, 1}},
+		},
+		{
+			name:       "corner completes a capture of black",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".......",
+				"...k...",
+				".......",
+				"....b..",
+				".......",
+				".b..w..",
+			},
+			move:     Move{From: Coord{6, 4}, To: Coord{6, 2}},
+			captured: []Coord{{6, 1}},
+		},
+		{
+			name:       "empty throne completes a capture of black",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".....b.",
+				".......",
+				"..b....",
+				".......",
+				".w...k.",
+				".......",
+			},
+			move:     Move{From: Coord{5, 1}, To: Coord{3, 1}},
+			captured: []Coord{{3, 2}},
+		},
+		{
+			name:       "empty throne is not hostile to white by default",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".......",
+				".......",
+				"..w....",
+				".......",
+				".b...k.",
+				".....w.",
+			},
+			move:     Move{From: Coord{5, 1}, To: Coord{3, 1}},
+			captured: nil,
+		},
+		{
+			name:       "empty throne is hostile to white when the rules say so",
+			rules:      RuleSet{EmptyThroneHostileToDefenders: true},
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".......",
+				".b.....",
+				"..w....",
+				"....k..",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{2, 1}, To: Coord{3, 1}},
+			captured: []Coord{{3, 2}},
+		},
+		{
+			name:       "occupied throne is hostile to black",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".....b.",
+				".......",
+				"..bk...",
+				".......",
+				".w.....",
+				".......",
+			},
+			move:     Move{From: Coord{5, 1}, To: Coord{3, 1}},
+			captured: []Coord{{3, 2}},
+		},
+		{
+			name:       "unarmed king does not help capture",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".....b.",
+				"..bk...",
+				".......",
+				".......",
+				".w.....",
+				".......",
+			},
+			move:     Move{From: Coord{5, 1}, To: Coord{2, 1}},
+			captured: nil,
+		},
+		{
+			name:       "armed king helps capture",
+			rules:      armed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".....b.",
+				"..bk...",
+				".......",
+				".......",
+				".w.....",
+				".......",
+			},
+			move:     Move{From: Coord{5, 1}, To: Coord{2, 1}},
+			captured: []Coord{{2, 2}},
+		},
+		{
+			name:       "unarmed king does not capture",
+			rules:      unarmed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".w.....",
+				".b.....",
+				"...k...",
+				".......",
+				".....b.",
+				".......",
+			},
+			move:     Move{From: Coord{3, 3}, To: Coord{3, 1}},
+			captured: nil,
+		},
+		{
+			name:       "armed king captures",
+			rules:      armed,
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				".w.....",
+				".b.....",
+				"...k...",
+				".......",
+				".....b.",
+				".......",
+			},
+			move:     Move{From: Coord{3, 3}, To: Coord{3, 1}},
+			captured: []Coord{{2, 1}},
+		},
+		{
+			name:       "king on four sides",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				"..b....",
+				".bkb...",
+				".......",
+				"..b....",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{4, 2}, To: Coord{3, 2}},
+			captured: []Coord{{2, 2}},
+		},
+		{
+			name:       "king on three sides is not captured",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".......",
+				".bkb...",
+				".......",
+				"..b....",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{4, 2}, To: Coord{3, 2}},
+			captured: nil,
+		},
+		{
+			name:       "king on three sides and the throne",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				"...b...",
+				"..bk..b",
+				".......",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{2, 6}, To: Coord{2, 4}},
+			captured: []Coord{{2, 3}},
+		},
+		{
+			name:       "king on the edge cannot be captured on four sides",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				"..bkb..",
+				".......",
+				"...b...",
+				".......",
+				".......",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{2, 3}, To: Coord{1, 3}},
+			captured: nil,
+		},
+		{
+			name:       "king on two sides",
+			rules:      RuleSet{KingCapture: KingCapturedOnTwoSides},
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".bk...b",
+				".......",
+				".......",
+				".......",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{1, 6}, To: Coord{1, 3}},
+			captured: []Coord{{1, 2}},
+		},
+		{
+			name:       "king on two sides against the edge is safe",
+			rules:      RuleSet{KingCapture: KingCapturedOnTwoSides},
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				"k....b.",
+				".......",
+				".......",
+				".......",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{1, 5}, To: Coord{1, 1}},
+			captured: nil,
+		},
+		{
+			name:       "king away from the throne on two sides",
+			rules:      RuleSet{KingCapture: KingCapturedOnFourSidesAtThrone},
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".bk...b",
+				".......",
+				".......",
+				".......",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{1, 6}, To: Coord{1, 3}},
+			captured: []Coord{{1, 2}},
+		},
+		{
+			name:       "king next to the throne needs four sides",
+			rules:      RuleSet{KingCapture: KingCapturedOnFourSidesAtThrone},
+			blacksTurn: true,
+			setup: []string{
+				".......",
+				".......",
+				"..bk..b",
+				".......",
+				".......",
+				"......w",
+				".......",
+			},
+			move:     Move{From: Coord{2, 6}, To: Coord{2, 4}},
+			captured: nil,
+		},
+		{
+			name:       "shieldwall",
+			rules:      RuleSet{Shieldwall: true},
+			blacksTurn: true,
+			setup: []string{
+				".bww...",
+				"..bb...",
+				"....b..",
+				"...k...",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{2, 4}, To: Coord{0, 4}},
+			captured: []Coord{{0, 2}, {0, 3}},
+		},
+		{
+			name:       "shieldwall needs the rule",
+			rules:      unarmed,
+			blacksTurn: true,
+			setup: []string{
+				".bww...",
+				"..bb...",
+				"....b..",
+				"...k...",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{2, 4}, To: Coord{0, 4}},
+			captured: nil,
+		},
+		{
+			name:       "shieldwall needs every piece fronted",
+			rules:      RuleSet{Shieldwall: true},
+			blacksTurn: true,
+			setup: []string{
+				".bww...",
+				"..b....",
+				"....b..",
+				"...k...",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{2, 4}, To: Coord{0, 4}},
+			captured: nil,
+		},
+		{
+			name:       "shieldwall bracketed by a corner",
+			rules:      RuleSet{Shieldwall: true},
+			blacksTurn: true,
+			setup: []string{
+				".ww....",
+				".bb....",
+				"...b...",
+				"...k...",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{2, 3}, To: Coord{0, 3}},
+			captured: []Coord{{0, 1}, {0, 2}},
+		},
+		{
+			name:       "king in a shieldwall is not captured",
+			rules:      RuleSet{Shieldwall: true},
+			blacksTurn: true,
+			setup: []string{
+				".bwk...",
+				"..bb...",
+				"....b..",
+				".......",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{2, 4}, To: Coord{0, 4}},
+			captured: []Coord{{0, 2}},
+		},
+		{
+			name:       "white captures a shieldwall",
+			rules:      RuleSet{Shieldwall: true},
+			blacksTurn: false,
+			setup: []string{
+				".......",
+				"...k...",
+				".......",
+				".......",
+				"....w..",
+				"..ww...",
+				".wbb...",
+			},
+			move:     Move{From: Coord{4, 4}, To: Coord{6, 4}},
+			captured: []Coord{{6, 2}, {6, 3}},
+		},
+		{
+			name:       "shieldwall is only captured by a bracketing piece",
+			rules:      RuleSet{Shieldwall: true},
+			blacksTurn: true,
+			setup: []string{
+				".bwwb..",
+				"..b...b",
+				".......",
+				"...k...",
+				".......",
+				".....w.",
+				".......",
+			},
+			move:     Move{From: Coord{1, 6}, To: Coord{1, 3}},
+			captured: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newVariant("test", "Test", tt.rules, tt.setup...)
+			p := NewPosition(v)
+			p.BlacksTurn = tt.blacksTurn
+
+			before := p
+			blackPawns, whitePawns := p.BlackPawns, p.WhitePawns
+			kingCaptured := false
+
+			if err := p.Apply(tt.move); err != nil {
+				t.Fatalf("Apply(%v): %v", tt.move, err)
+			}
+
+			for _, c := range tt.captured {
+				if p.HasPiece(c) {
+					t.Errorf("piece on %v was not captured", c)
+				}
+
+				switch {
+				case before.IsKing(c):
+					kingCaptured = true
+				case before.IsBlack(c):
+					blackPawns--
+				default:
+					whitePawns--
+				}
+			}
+
+			if p.BlackPawns != blackPawns || p.WhitePawns != whitePawns {
+				t.Errorf("pawns = %v black, %v white; want %v black, %v white", p.BlackPawns, p.WhitePawns, blackPawns, whitePawns)
+			}
+
+			if p.KingCaptured != kingCaptured {
+				t.Errorf("KingCaptured = %v, want %v", p.KingCaptured, kingCaptured)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package rules
+
+// HasEdgeFort reports whether the king sits on the board edge, can move,
+// and is walled in by white pieces black can never capture. The squares
+// the king can reach are the fort's inside; every piece bordering them
+// must be white and safe. The white pieces joined to the wall may hold it
+// up too, so safety is settled for the whole group at once: a piece is
+// safe while, along both lines through it, it has a neighbour black can
+// never occupy, which is the board edge, the inside of the fort or
+// another safe piece. With shieldwalls, a piece on the edge must also be
+// in a row black cannot get in front of or close.
+func (p *Position) HasEdgeFort() bool {
+	king, ok := p.KingSquare()
+	if !ok || !p.IsOnEdge(king) || !p.kingCanMove(king) {
+		return false
+	}
+
+	var inside [MaxSquaresPerRow * MaxSquaresPerRow]bool
+	var group [MaxSquaresPerRow * MaxSquaresPerRow]bool
+	var walls []Coord
+
+	index := func(c Coord) int {
+		return c.Row*p.Size() + c.Col
+	}
+
+	inside[index(king)] = true
+	stack := []Coord{king}
+
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for _, d := range directions {
+			n := c.Add(d)
+
+			if !p.InBounds(n) {
+				continue
+			}
+
+			switch {
+			case p.IsBlack(n):
+				return false
+			case p.HasPiece(n):
+				if !group[index(n)] {
+					group[index(n)] = true
+					walls = append(walls, n)
+				}
+			case !inside[index(n)]:
+				inside[index(n)] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+
+	members := append([]Coord(nil), walls...)
+	for i := 0; i < len(members); i++ {
+		for _, d := range directions {
+			n := members[i].Add(d)
+
+			if p.InBounds(n) && p.HasPiece(n) && !p.IsBlack(n) && !inside[index(n)] && !group[index(n)] {
+				group[index(n)] = true
+				members = append(members, n)
+			}
+		}
+	}
+
+	safe := func(c Coord) bool {
+		return !p.InBounds(c) || inside[index(c)] || group[index(c)]
+	}
+
+	// shielded reports whether the row of safe pieces along the edge
+	// through c has a piece black cannot get in front of, or an end on
+	// the inside of the fort.
+	shielded := func(c Coord) bool {
+		for _, in := range p.inwardDirections(c) {
+			for _, along := range [...]Coord{{Row: in.Col, Col: in.Row}, {Row: -in.Col, Col: -in.Row}} {
+				n := c
+				for p.InBounds(n) && group[index(n)] {
+					if safe(n.Add(in)) {
+						return true
+					}
+
+					n = n.Add(along)
+				}
+
+				if p.InBounds(n) && inside[index(n)] {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	holds := func(c Coord) bool {
+		if !(safe(c.Add(West)) || safe(c.Add(East))) || !(safe(c.Add(North)) || safe(c.Add(South))) {
+			return false
+		}
+
+		return !p.Rules.Shieldwall || !p.IsOnEdge(c) || shielded(c)
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, c := range members {
+			if group[index(c)] && !holds(c) {
+				group[index(c)] = false
+				changed = true
+			}
+		}
+	}
+
+	for _, c := range walls {
+		if !group[index(c)] {
+			return false
+		}
+	}
+
+	if p.kingNeedsFourSides(king) {
+		return true
+	}
+
+	return (safe(king.Add(West)) || safe(king.Add(East))) && (safe(king.Add(North)) || safe(king.Add(South)))
+}
+
+func (p *Position) kingCanMove(king Coord) bool {
+	return !p.targets(king).IsEmpty()
+}
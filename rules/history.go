@@ -0,0 +1,53 @@
+package rules
+
+import (
+	board "github.com/technologyfreak/hnefatafl/board"
+)
+
+// Hash is the Zobrist hash of the piece placement and the side to move.
+func (p *Position) Hash() uint64 {
+	if p.BlacksTurn {
+		return p.layout.Hash() ^ board.ZobristBlackToMove
+	}
+
+	return p.layout.Hash()
+}
+
+// history is a list of hashes, newest first. Nodes are never changed once
+// made, so copies of a Position share their past and each move adds one
+// node.
+type history struct {
+	hash uint64
+	prev *history
+}
+
+// remember records the current position.
+func (p *Position) remember() {
+	p.history = &history{hash: p.Hash(), prev: p.history}
+}
+
+// SetToMove hands the move to side s, as when a position is set up with s
+// to play, and judges the position again from that side's point of view.
+func (p *Position) SetToMove(s Side) {
+	p.BlacksTurn = s == Black
+
+	if p.history != nil {
+		p.history = &history{hash: p.Hash(), prev: p.history.prev}
+	}
+
+	p.updateOutcome()
+}
+
+// Repetitions counts how often the current position has been seen,
+// itself included.
+func (p *Position) Repetitions() int {
+	count := 0
+
+	for h := p.history; h != nil; h = h.prev {
+		if h.hash == p.history.hash {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,81 @@
+package rules
+
+import (
+	board "github.com/technologyfreak/hnefatafl/board"
+)
+
+type Move struct {
+	From Coord
+	To   Coord
+}
+
+// open is the set of squares the king, or any other piece, may move
+// through. Only the king may pass over the empty throne unless the rules
+// allow it.
+func (p *Position) open(king bool) board.Bitboard {
+	open := p.geometry().All.AndNot(p.layout.Occupied())
+
+	if !king && !p.Rules.PassThroughThrone {
+		open = open.AndNot(p.Variant.throne)
+	}
+
+	return open
+}
+
+// targets is the set of squares the piece on from can move to. Only the
+// king may stop on the throne or a corner.
+func (p *Position) targets(from Coord) board.Bitboard {
+	g := p.geometry()
+
+	if p.IsKing(from) {
+		return g.Slides(p.index(from), p.open(true))
+	}
+
+	return g.Slides(p.index(from), p.open(false)).AndNot(p.Variant.throne).AndNot(p.Variant.corners)
+}
+
+func (p *Position) IsLegal(m Move) bool {
+	if p.outcome.IsOver() || !p.InBounds(m.From) || !p.InBounds(m.To) || !p.belongsToMover(m.From) {
+		return false
+	}
+
+	return p.targets(m.From).Has(p.index(m.To))
+}
+
+func (p *Position) MovesFrom(from Coord) []Move {
+	var moves []Move
+
+	if p.outcome.IsOver() || !p.InBounds(from) || !p.belongsToMover(from) {
+		return moves
+	}
+
+	for to := p.targets(from); !to.IsEmpty(); {
+		moves = append(moves, Move{From: from, To: p.coord(to.PopLowest())})
+	}
+
+	return moves
+}
+
+func (p *Position) LegalMoves() []Move {
+	var moves []Move
+
+	for from := p.movers(); !from.IsEmpty(); {
+		moves = append(moves, p.MovesFrom(p.coord(from.PopLowest()))...)
+	}
+
+	return moves
+}
+
+// hasLegalMove moves all the mover's pawns at once, and the king.
+func (p *Position) hasLegalMove() bool {
+	g := p.geometry()
+	movers := p.movers()
+
+	if !g.Reach(movers.And(p.layout.King), p.open(true)).IsEmpty() {
+		return true
+	}
+
+	pawns := g.Reach(movers.AndNot(p.layout.King), p.open(false))
+
+	return !pawns.AndNot(p.Variant.throne).AndNot(p.Variant.corners).IsEmpty()
+}
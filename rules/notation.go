@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatCoord names square c the way tafl records do: files are lettered
+// from a on the left and ranks are numbered from 1 at the bottom.
+func FormatCoord(c Coord, size int) string {
+	return string(rune('a'+c.Col)) + strconv.Itoa(size-c.Row)
+}
+
+func ParseCoord(s string, size int) (Coord, error) {
+	if len(s) < 2 || s[0] < 'a' || s[0] > 'z' {
+		return Coord{}, fmt.Errorf("bad square %q", s)
+	}
+
+	rank, err := strconv.Atoi(s[1:])
+	if err != nil {
+		return Coord{}, fmt.Errorf("bad square %q", s)
+	}
+
+	c := Coord{Row: size - rank, Col: int(s[0] - 'a')}
+	if c.Row < 0 || c.Row >= size || c.Col >= size {
+		return Coord{}, fmt.Errorf("square %q is off the board", s)
+	}
+
+	return c, nil
+}
+
+// FormatMove writes m as the two squares joined by a dash, such as a4-a7.
+func FormatMove(m Move, size int) string {
+	return FormatCoord(m.From, size) + "-" + FormatCoord(m.To, size)
+}
+
+func ParseMove(s string, size int) (Move, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Move{}, fmt.Errorf("bad move %q", s)
+	}
+
+	var m Move
+	var err error
+
+	if m.From, err = ParseCoord(from, size); err != nil {
+		return Move{}, err
+	}
+
+	if m.To, err = ParseCoord(to, size); err != nil {
+		return Move{}, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,38 @@
+package rules
+
+import (
+	"testing"
+)
+
+func TestNotation(t *testing.T) {
+	tests := []struct {
+		text string
+		size int
+		move Move
+	}{
+		{"a1-a4", 11, Move{From: Coord{10, 0}, To: Coord{7, 0}}},
+		{"k11-f11", 11, Move{From: Coord{0, 10}, To: Coord{0, 5}}},
+		{"d4-d7", 7, Move{From: Coord{3, 3}, To: Coord{0, 3}}},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMove(tt.text, tt.size)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.text, err)
+		}
+
+		if m != tt.move {
+			t.Errorf("%v: got %v, want %v", tt.text, m, tt.move)
+		}
+
+		if got := FormatMove(m, tt.size); got != tt.text {
+			t.Errorf("got %v, want %v", got, tt.text)
+		}
+	}
+
+	for _, bad := range []string{"", "a1", "a1-", "a0-a4", "l1-a1", "a12-a1", "1a-a1"} {
+		if _, err := ParseMove(bad, 11); err == nil {
+			t.Errorf("%q: parsed", bad)
+		}
+	}
+}
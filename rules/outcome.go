@@ -0,0 +1,273 @@
+package rules
+
+import (
+	"fmt"
+
+	board "github.com/technologyfreak/hnefatafl/board"
+)
+
+// Side is one of the two players. Black attacks and white defends.
+type Side uint8
+
+const (
+	Black Side = iota
+	White
+)
+
+func (s Side) Opponent() Side {
+	return 1 - s
+}
+
+func (s Side) String() string {
+	if s == Black {
+		return "Black"
+	}
+
+	return "White"
+}
+
+func (s Side) Wins() Result {
+	if s == Black {
+		return BlackWins
+	}
+
+	return WhiteWins
+}
+
+type Result uint8
+
+const (
+	InProgress Result = iota
+	BlackWins
+	WhiteWins
+	Draw
+)
+
+var resultNames = [...]string{
+	InProgress: "in progress",
+	BlackWins:  "black wins",
+	WhiteWins:  "white wins",
+	Draw:       "draw",
+}
+
+func (r Result) String() string {
+	if int(r) < len(resultNames) {
+		return resultNames[r]
+	}
+
+	return "unknown"
+}
+
+func ParseResult(s string) (Result, error) {
+	for i, name := range resultNames {
+		if s == name {
+			return Result(i), nil
+		}
+	}
+
+	return InProgress, fmt.Errorf("unknown result %q", s)
+}
+
+func (r Result) MarshalText() ([]byte, error) {
+	if int(r) >= len(resultNames) {
+		return nil, fmt.Errorf("unknown result %d", r)
+	}
+
+	return []byte(r.String()), nil
+}
+
+func (r *Result) UnmarshalText(text []byte) error {
+	var err error
+	*r, err = ParseResult(string(text))
+
+	return err
+}
+
+type Reason uint8
+
+const (
+	ReasonNone Reason = iota
+	ReasonKingCaptured
+	ReasonKingEscaped
+	ReasonEdgeFort
+	ReasonEncircled
+	ReasonAllPawnsCaptured
+	ReasonNoMoves
+	ReasonRepetition
+	ReasonMoveLimit
+	ReasonResignation
+	ReasonTimeout
+	ReasonAbandoned
+)
+
+var reasonNames = [...]string{
+	ReasonNone:             "",
+	ReasonKingCaptured:     "king captured",
+	ReasonKingEscaped:      "king escaped",
+	ReasonEdgeFort:         "edge fort",
+	ReasonEncircled:        "encircled",
+	ReasonAllPawnsCaptured: "all pawns captured",
+	ReasonNoMoves:          "no legal moves",
+	ReasonRepetition:       "repetition",
+	ReasonMoveLimit:        "move limit",
+	ReasonResignation:      "resignation",
+	ReasonTimeout:          "timeout",
+	ReasonAbandoned:        "abandoned",
+}
+
+func (r Reason) String() string {
+	if int(r) < len(reasonNames) {
+		return reasonNames[r]
+	}
+
+	return "unknown"
+}
+
+func ParseReason(s string) (Reason, error) {
+	for i, name := range reasonNames {
+		if s == name {
+			return Reason(i), nil
+		}
+	}
+
+	return ReasonNone, fmt.Errorf("unknown reason %q", s)
+}
+
+func (r Reason) MarshalText() ([]byte, error) {
+	if int(r) >= len(reasonNames) {
+		return nil, fmt.Errorf("unknown reason %d", r)
+	}
+
+	return []byte(r.String()), nil
+}
+
+func (r *Reason) UnmarshalText(text []byte) error {
+	var err error
+	*r, err = ParseReason(string(text))
+
+	return err
+}
+
+// Outcome says whether a game is over, who won and why. Like RuleSet, its
+// JSON goes out to the browser client.
+type Outcome struct {
+	Result Result `json:"result"`
+	Reason Reason `json:"reason"`
+}
+
+func (o Outcome) IsOver() bool {
+	return o.Result != InProgress
+}
+
+// Winner returns the side that won, or false for a game that is still
+// going or drawn.
+func (o Outcome) Winner() (Side, bool) {
+	switch o.Result {
+	case BlackWins:
+		return Black, true
+	case WhiteWins:
+		return White, true
+	}
+
+	return Black, false
+}
+
+func (o Outcome) String() string {
+	if o.Reason == ReasonNone {
+		return o.Result.String()
+	}
+
+	return o.Result.String() + " (" + o.Reason.String() + ")"
+}
+
+func (p *Position) ToMove() Side {
+	if p.BlacksTurn {
+		return Black
+	}
+
+	return White
+}
+
+// Outcome is worked out after every move.
+func (p *Position) Outcome() Outcome {
+	return p.outcome
+}
+
+func (p *Position) updateOutcome() {
+	p.outcome = p.judge()
+}
+
+// Resign ends the game in favour of the side that did not resign.
+func (p *Position) Resign(s Side) {
+	p.end(s.Opponent().Wins(), ReasonResignation)
+}
+
+// Timeout ends the game against the side that ran out of time.
+func (p *Position) Timeout(s Side) {
+	p.end(s.Opponent().Wins(), ReasonTimeout)
+}
+
+// Abandon ends the game against the side that left it and did not come
+// back.
+func (p *Position) Abandon(s Side) {
+	p.end(s.Opponent().Wins(), ReasonAbandoned)
+}
+
+func (p *Position) end(result Result, reason Reason) {
+	if !p.outcome.IsOver() {
+		p.outcome = Outcome{Result: result, Reason: reason}
+	}
+}
+
+func (p *Position) judge() Outcome {
+	switch {
+	case p.KingCaptured:
+		return Outcome{BlackWins, ReasonKingCaptured}
+	case p.KingHasEscaped():
+		return Outcome{WhiteWins, ReasonKingEscaped}
+	case p.Rules.EdgeForts && p.HasEdgeFort():
+		return Outcome{WhiteWins, ReasonEdgeFort}
+	case p.WhitePawns == 0:
+		return Outcome{BlackWins, ReasonAllPawnsCaptured}
+	case p.BlackPawns == 0:
+		return Outcome{WhiteWins, ReasonAllPawnsCaptured}
+	case p.IsEncircled():
+		return Outcome{BlackWins, ReasonEncircled}
+	case !p.hasLegalMove():
+		return Outcome{p.ToMove().Opponent().Wins(), ReasonNoMoves}
+	case p.Rules.Repetition == RepetitionDraws && p.Repetitions() >= 3:
+		return Outcome{Draw, ReasonRepetition}
+	case p.Rules.Repetition == RepetitionLoses && p.Repetitions() >= 3:
+		// The side that just moved is the one repeating.
+		return Outcome{p.ToMove().Wins(), ReasonRepetition}
+	case p.Rules.MoveLimit > 0 && p.MoveCount >= p.Rules.MoveLimit:
+		return Outcome{Draw, ReasonMoveLimit}
+	}
+
+	return Outcome{InProgress, ReasonNone}
+}
+
+// IsEncircled reports whether black has closed an unbroken ring around
+// every white piece: a flood fill from the board edge through every square
+// black does not hold never reaches one.
+func (p *Position) IsEncircled() bool {
+	g := p.geometry()
+	open := g.All.AndNot(p.layout.Black)
+	reached := g.Edges.And(open)
+
+	for {
+		next := reached
+
+		for _, d := range board.Directions {
+			next = next.Or(g.Shift(reached, d).And(open))
+		}
+
+		if next == reached {
+			break
+		}
+
+		reached = next
+	}
+
+	return reached.And(p.layout.White).IsEmpty()
+}
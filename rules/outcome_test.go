@@ -0,0 +1,282 @@
+package rules
+
+import (
+	"testing"
+)
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  []string
+		adjust func(*RuleSet)
+		result Result
+		reason Reason
+	}{
+		{
+			name: "in progress",
+			setup: []string{
+				"...b...",
+				".......",
+				".......",
+				"...k...",
+				"...w...",
+				".......",
+				".......",
+			},
+			result: InProgress,
+			reason: ReasonNone,
+		},
+		{
+			name: "encircled",
+			setup: []string{
+				".......",
+				"..bbb..",
+				".b...b.",
+				".b.k.b.",
+				".b.w.b.",
+				"..bbb..",
+				".......",
+			},
+			result: BlackWins,
+			reason: ReasonEncircled,
+		},
+		{
+			name: "gap in the ring",
+			setup: []string{
+				".......",
+				"..bbb..",
+				".b...b.",
+				".b.k.b.",
+				".b.w.b.",
+				"..b.b..",
+				".......",
+			},
+			result: InProgress,
+			reason: ReasonNone,
+		},
+		{
+			name: "white piece outside the ring",
+			setup: []string{
+				".......",
+				"..bbb..",
+				".b...b.",
+				".b.k.b.",
+				".b...b.",
+				"..bbb..",
+				".....w.",
+			},
+			result: InProgress,
+			reason: ReasonNone,
+		},
+		{
+			name: "no legal moves",
+			setup: []string{
+				".bw....",
+				".w.....",
+				".......",
+				"...k...",
+				".......",
+				".......",
+				".......",
+			},
+			result: WhiteWins,
+			reason: ReasonNoMoves,
+		},
+		{
+			name: "edge fort",
+			setup: []string{
+				".......",
+				"...b...",
+				".......",
+				".......",
+				"..www..",
+				".w...w.",
+				".w.k.w.",
+			},
+			result: WhiteWins,
+			reason: ReasonEdgeFort,
+		},
+		{
+			name: "fort held up from outside",
+			setup: []string{
+				".......",
+				"...b...",
+				".......",
+				".......",
+				"..www..",
+				"..w.w..",
+				"..wk.w.",
+			},
+			result: WhiteWins,
+			reason: ReasonEdgeFort,
+		},
+		{
+			name: "fort held up from outside without shieldwalls",
+			setup: []string{
+				".......",
+				"...b...",
+				".......",
+				".......",
+				"..www..",
+				"..w.w..",
+				"..wk.w.",
+			},
+			adjust: func(r *RuleSet) { r.Shieldwall = false },
+			result: WhiteWins,
+			reason: ReasonEdgeFort,
+		},
+		{
+			name: "open fort",
+			setup: []string{
+				".......",
+				"...b...",
+				".......",
+				".......",
+				"..w.w..",
+				".w...w.",
+				".w.k.w.",
+			},
+			result: InProgress,
+			reason: ReasonNone,
+		},
+		{
+			name: "fort with a weak wall",
+			setup: []string{
+				".......",
+				"...b...",
+				".......",
+				".......",
+				"...w...",
+				"..w.w..",
+				"..wkw..",
+			},
+			result: InProgress,
+			reason: ReasonNone,
+		},
+		{
+			name: "king cannot move inside the fort",
+			setup: []string{
+				".......",
+				"...b...",
+				".......",
+				".......",
+				".......",
+				"..www..",
+				"..wkw..",
+			},
+			result: InProgress,
+			reason: ReasonNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := CopenhagenRules
+			if tt.adjust != nil {
+				tt.adjust(&r)
+			}
+
+			p := NewPosition(newVariant("test", "Test", r, tt.setup...))
+
+			if got, want := p.Outcome(), (Outcome{tt.result, tt.reason}); got != want {
+				t.Errorf("Outcome() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRepetitionAndMoveLimit(t *testing.T) {
+	setup := []string{
+		"...b...",
+		".......",
+		".......",
+		"...k...",
+		"...w...",
+		".......",
+		".......",
+	}
+
+	shuffle := []Move{
+		{From: Coord{0, 3}, To: Coord{0, 2}},
+		{From: Coord{4, 3}, To: Coord{4, 2}},
+		{From: Coord{0, 2}, To: Coord{0, 3}},
+		{From: Coord{4, 2}, To: Coord{4, 3}},
+	}
+
+	tests := []struct {
+		name   string
+		rules  RuleSet
+		moves  int
+		result Result
+		reason Reason
+	}{
+		{"repetition allowed", RuleSet{}, 8, InProgress, ReasonNone},
+		{"twice is not a draw", RuleSet{Repetition: RepetitionDraws}, 7, InProgress, ReasonNone},
+		{"threefold repetition draws", RuleSet{Repetition: RepetitionDraws}, 8, Draw, ReasonRepetition},
+		{"repeating side loses", RuleSet{Repetition: RepetitionLoses}, 8, BlackWins, ReasonRepetition},
+		{"move limit", RuleSet{MoveLimit: 5}, 5, Draw, ReasonMoveLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPosition(newVariant("test", "Test", tt.rules, setup...))
+
+			for i := 0; i < tt.moves; i++ {
+				if err := p.Apply(shuffle[i%len(shuffle)]); err != nil {
+					t.Fatalf("move %v: %v", i, err)
+				}
+			}
+
+			if got, want := p.Outcome(), (Outcome{tt.result, tt.reason}); got != want {
+				t.Errorf("Outcome() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	// A copy shares the history so far, but not what either side plays
+	// after it.
+	p := NewPosition(newVariant("test", "Test", RuleSet{}, setup...))
+	for _, m := range shuffle {
+		if err := p.Apply(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := p
+	for _, m := range shuffle {
+		if err := q.Apply(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if p.Repetitions() != 2 || q.Repetitions() != 3 {
+		t.Errorf("Repetitions() = %v and %v, want 2 and 3", p.Repetitions(), q.Repetitions())
+	}
+}
+
+func TestSetToMove(t *testing.T) {
+	// Black's only pawn is shut in beside a corner.
+	p := NewPosition(newVariant("test", "Test", BrandubhRules,
+		".bw....",
+		".w.....",
+		".......",
+		"...k...",
+		".......",
+		".......",
+		".......",
+	))
+
+	if got, want := p.Outcome(), (Outcome{WhiteWins, ReasonNoMoves}); got != want {
+		t.Fatalf("Outcome() = %v, want %v", got, want)
+	}
+
+	p.SetToMove(White)
+
+	if got := p.Outcome(); got.IsOver() {
+		t.Errorf("white to move: Outcome() = %v", got)
+	}
+
+	if len(p.LegalMoves()) == 0 {
+		t.Error("white has no moves")
+	}
+}
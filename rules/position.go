@@ -0,0 +1,190 @@
+package rules
+
+import (
+	"errors"
+
+	board "github.com/technologyfreak/hnefatafl/board"
+	piece "github.com/technologyfreak/hnefatafl/piece"
+)
+
+var ErrIllegalMove = errors.New("illegal move")
+
+type Coord struct {
+	Row int
+	Col int
+}
+
+func (c Coord) Add(d Coord) Coord {
+	return Coord{Row: c.Row + d.Row, Col: c.Col + d.Col}
+}
+
+type Position struct {
+	Variant *Variant
+	Rules   RuleSet
+
+	layout board.Layout
+
+	BlackPawns uint8
+	WhitePawns uint8
+
+	BlacksTurn   bool
+	KingCaptured bool
+
+	MoveCount int
+	// history holds the hash of every position since the last capture;
+	// nothing before a capture can come round again.
+	history *history
+
+	outcome Outcome
+}
+
+func NewPosition(v *Variant) Position {
+	return NewPositionWithRules(v, v.Rules)
+}
+
+// NewPositionWithRules starts a game of v played under r rather than the
+// variant's own rules.
+func NewPositionWithRules(v *Variant, r RuleSet) Position {
+	p := Position{Variant: v, Rules: r}
+	p.layout.SquaresPerRow = v.Size
+
+	for row, line := range v.Setup {
+		for col, r := range line {
+			kind := piece.None
+
+			switch r {
+			case 'b':
+				kind = piece.BlackPawn
+			case 'w':
+				kind = piece.WhitePawn
+			case 'k':
+				kind = piece.King | piece.WhitePawn
+			}
+
+			p.set(Coord{Row: row, Col: col}, kind)
+		}
+	}
+
+	p.BlackPawns = v.BlackPawns
+	p.WhitePawns = v.WhitePawns
+	p.BlacksTurn = true
+	p.remember()
+	p.updateOutcome()
+
+	return p
+}
+
+func (p *Position) Size() int {
+	return p.Variant.Size
+}
+
+func (p *Position) geometry() *board.Geometry {
+	return board.GeometryFor(p.Size())
+}
+
+func (p *Position) index(c Coord) int {
+	return c.Row*p.Size() + c.Col
+}
+
+func (p *Position) coord(i int) Coord {
+	return Coord{Row: i / p.Size(), Col: i % p.Size()}
+}
+
+// Layout returns the bitboards behind the position.
+func (p *Position) Layout() board.Layout {
+	return p.layout
+}
+
+func (p *Position) InBounds(c Coord) bool {
+	return c.Row >= 0 && c.Row < p.Size() && c.Col >= 0 && c.Col < p.Size()
+}
+
+func (p *Position) IsThrone(c Coord) bool {
+	return c == p.Variant.Throne
+}
+
+func (p *Position) IsKingsCorner(c Coord) bool {
+	return p.InBounds(c) && p.Variant.corners.Has(p.index(c))
+}
+
+func (p *Position) At(c Coord) piece.PieceKind {
+	return p.layout.At(p.index(c))
+}
+
+func (p *Position) set(c Coord, kind piece.PieceKind) {
+	p.layout.Put(p.index(c), kind)
+}
+
+func (p *Position) HasPiece(c Coord) bool {
+	return p.At(c) != piece.None
+}
+
+func (p *Position) IsBlack(c Coord) bool {
+	return p.At(c)&piece.BlackPawn == piece.BlackPawn
+}
+
+func (p *Position) IsOnEdge(c Coord) bool {
+	return p.InBounds(c) && p.geometry().Edges.Has(p.index(c))
+}
+
+func (p *Position) IsKing(c Coord) bool {
+	return p.At(c)&piece.King == piece.King
+}
+
+// KingSquare finds the king, or returns false once he has been captured.
+func (p *Position) KingSquare() (Coord, bool) {
+	king := p.layout.King
+	if king.IsEmpty() {
+		return Coord{}, false
+	}
+
+	return p.coord(king.PopLowest()), true
+}
+
+func (p *Position) belongsToMover(c Coord) bool {
+	return p.HasPiece(c) && p.IsBlack(c) == p.BlacksTurn
+}
+
+// movers is the set of pieces belonging to the side to move.
+func (p *Position) movers() board.Bitboard {
+	if p.BlacksTurn {
+		return p.layout.Black
+	}
+
+	return p.layout.White
+}
+
+func (p *Position) KingHasReachedACorner() bool {
+	return !p.layout.King.And(p.Variant.corners).IsEmpty()
+}
+
+func (p *Position) KingHasEscaped() bool {
+	if p.Rules.Escape == CornerEscape {
+		return p.KingHasReachedACorner()
+	}
+
+	return !p.layout.King.And(p.geometry().Edges).IsEmpty()
+}
+
+func (p *Position) Apply(m Move) error {
+	if !p.IsLegal(m) {
+		return ErrIllegalMove
+	}
+
+	p.set(m.To, p.At(m.From))
+	p.set(m.From, piece.None)
+
+	blackPawns, whitePawns := p.BlackPawns, p.WhitePawns
+	p.capture(m.To)
+
+	if p.BlackPawns != blackPawns || p.WhitePawns != whitePawns {
+		p.history = nil
+	}
+
+	p.BlacksTurn = !p.BlacksTurn // toggle turn order
+	p.MoveCount++
+	p.remember()
+	p.updateOutcome()
+
+	return nil
+}
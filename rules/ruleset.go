@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"fmt"
+)
+
+type KingCapture uint8
+
+const (
+	// The king is surrounded on all four sides, the throne counting as one.
+	KingCapturedOnFourSides KingCapture = iota
+	// The king is sandwiched like any pawn.
+	KingCapturedOnTwoSides
+	// Four sides on or next to the throne, two sides everywhere else.
+	KingCapturedOnFourSidesAtThrone
+)
+
+var kingCaptureNames = [...]string{"4", "2", "4-at-throne"}
+
+func (k KingCapture) String() string {
+	if int(k) < len(kingCaptureNames) {
+		return kingCaptureNames[k]
+	}
+
+	return fmt.Sprintf("KingCapture(%d)", k)
+}
+
+func ParseKingCapture(s string) (KingCapture, error) {
+	for i, name := range kingCaptureNames {
+		if s == name {
+			return KingCapture(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown king capture %q", s)
+}
+
+func (k KingCapture) MarshalText() ([]byte, error) {
+	if int(k) >= len(kingCaptureNames) {
+		return nil, fmt.Errorf("unknown king capture %d", k)
+	}
+
+	return []byte(k.String()), nil
+}
+
+func (k *KingCapture) UnmarshalText(text []byte) error {
+	var err error
+	*k, err = ParseKingCapture(string(text))
+
+	return err
+}
+
+type Escape uint8
+
+const (
+	CornerEscape Escape = iota
+	EdgeEscape
+)
+
+var escapeNames = [...]string{"corner", "edge"}
+
+func (e Escape) String() string {
+	if int(e) < len(escapeNames) {
+		return escapeNames[e]
+	}
+
+	return fmt.Sprintf("Escape(%d)", e)
+}
+
+func ParseEscape(s string) (Escape, error) {
+	for i, name := range escapeNames {
+		if s == name {
+			return Escape(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown escape %q", s)
+}
+
+func (e Escape) MarshalText() ([]byte, error) {
+	if int(e) >= len(escapeNames) {
+		return nil, fmt.Errorf("unknown escape %d", e)
+	}
+
+	return []byte(e.String()), nil
+}
+
+func (e *Escape) UnmarshalText(text []byte) error {
+	var err error
+	*e, err = ParseEscape(string(text))
+
+	return err
+}
+
+type Repetition uint8
+
+const (
+	RepetitionAllowed Repetition = iota
+	// A position seen for the third time ends the game in a draw.
+	RepetitionDraws
+	// The side that brings about a position for the third time loses.
+	RepetitionLoses
+)
+
+var repetitionNames = [...]string{"allowed", "draw", "lose"}
+
+func (r Repetition) String() string {
+	if int(r) < len(repetitionNames) {
+		return repetitionNames[r]
+	}
+
+	return fmt.Sprintf("Repetition(%d)", r)
+}
+
+func ParseRepetition(s string) (Repetition, error) {
+	for i, name := range repetitionNames {
+		if s == name {
+			return Repetition(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown repetition rule %q", s)
+}
+
+func (r Repetition) MarshalText() ([]byte, error) {
+	if int(r) >= len(repetitionNames) {
+		return nil, fmt.Errorf("unknown repetition rule %d", r)
+	}
+
+	return []byte(r.String()), nil
+}
+
+func (r *Repetition) UnmarshalText(text []byte) error {
+	var err error
+	*r, err = ParseRepetition(string(text))
+
+	return err
+}
+
+// RuleSet holds the rules tafl clubs tend to disagree on.
+// The JSON names and the enums' names go out to the browser client, and
+// must stay put.
+type RuleSet struct {
+	KingArmed   bool        `json:"kingArmed"`
+	KingCapture KingCapture `json:"kingCapture"`
+	Escape      Escape      `json:"escape"`
+
+	EmptyThroneHostileToDefenders bool `json:"hostileThrone"`
+	PassThroughThrone             bool `json:"passThroughThrone"`
+
+	// Shieldwall lets a row of two or more pieces on the board edge be
+	// captured at once by bracketing both ends and fronting every piece.
+	Shieldwall bool `json:"shieldwall"`
+	// EdgeForts gives white the game when the king sits on the edge, can
+	// move, and is walled in by pieces black can never capture.
+	EdgeForts bool `json:"edgeForts"`
+
+	Repetition Repetition `json:"repetition"`
+	// MoveLimit ends the game in a draw once this many moves have been
+	// played by both sides together. Zero means no limit.
+	MoveLimit int `json:"moveLimit"`
+}
+
+var (
+	BrandubhRules = RuleSet{
+		KingArmed:                     true,
+		KingCapture:                   KingCapturedOnFourSidesAtThrone,
+		Escape:                        CornerEscape,
+		EmptyThroneHostileToDefenders: true,
+		PassThroughThrone:             true,
+		Repetition:                    RepetitionDraws,
+	}
+
+	TablutRules = RuleSet{
+		KingArmed:   true,
+		KingCapture: KingCapturedOnFourSidesAtThrone,
+		Escape:      EdgeEscape,
+		Repetition:  RepetitionDraws,
+	}
+
+	CopenhagenRules = RuleSet{
+		KingArmed:                     true,
+		KingCapture:                   KingCapturedOnFourSides,
+		Escape:                        CornerEscape,
+		EmptyThroneHostileToDefenders: true,
+		PassThroughThrone:             true,
+		Shieldwall:                    true,
+		EdgeForts:                     true,
+		Repetition:                    RepetitionLoses,
+	}
+
+	AleaEvangeliiRules = RuleSet{
+		KingCapture: KingCapturedOnFourSides,
+		Escape:      CornerEscape,
+		Repetition:  RepetitionDraws,
+	}
+)
@@ -0,0 +1,173 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	board "github.com/technologyfreak/hnefatafl/board"
+)
+
+const MaxSquaresPerRow = board.MaxSquaresPerRow
+
+// Variant describes a board: its size, starting setup and special squares.
+// Setup rows use 'b' for a black pawn, 'w' for a white pawn, 'k' for the
+// king and '.' for an empty square.
+type Variant struct {
+	ID   string
+	Name string
+	Size int
+
+	Setup []string
+
+	Throne  Coord
+	Corners []Coord
+
+	BlackPawns uint8
+	WhitePawns uint8
+
+	Rules RuleSet
+
+	throne  board.Bitboard
+	corners board.Bitboard
+}
+
+var (
+	Brandubh = newVariant("brandubh", "Brandubh", BrandubhRules,
+		"...b...",
+		"...b...",
+		"...w...",
+		"bbwkwbb",
+		"...w...",
+		"...b...",
+		"...b...",
+	)
+
+	Tablut = newVariant("tablut", "Tablut", TablutRules,
+		"...bbb...",
+		"....b....",
+		"....w....",
+		"b...w...b",
+		"bbwwkwwbb",
+		"b...w...b",
+		"....w....",
+		"....b....",
+		"...bbb...",
+	)
+
+	Copenhagen = newVariant("copenhagen", "Copenhagen", CopenhagenRules,
+		"...bbbbb...",
+		".....b.....",
+		"...........",
+		"b....w....b",
+		"b...www...b",
+		"bb.wwkww.bb",
+		"b...www...b",
+		"b....w....b",
+		"...........",
+		".....b.....",
+		"...bbbbb...",
+	)
+
+	Hnefatafl13 = newVariant("hnefatafl13", "Hnefatafl 13x13", CopenhagenRules,
+		"....bbbbb....",
+		"......b......",
+		".............",
+		".............",
+		"b.....w.....b",
+		"b....www....b",
+		"bb..wwkww..bb",
+		"b....www....b",
+		"b.....w.....b",
+		".............",
+		".............",
+		"......b......",
+		"....bbbbb....",
+	)
+
+	AleaEvangelii = newVariant("alea-evangelii", "Alea Evangelii", AleaEvangeliiRules,
+		"...b..b.....b..b...",
+		".....b.......b.....",
+		"....b..b...b..b....",
+		"b.................b",
+		"..b...b.....b...b..",
+		".b......w.w......b.",
+		"b...b....w....b...b",
+		"..b.....w.w.....b..",
+		".....w.w.w.w.w.....",
+		"......w.wkw.w......",
+		".....w.w.w.w.w.....",
+		"..b.....w.w.....b..",
+		"b...b....w....b...b",
+		".b......w.w......b.",
+		"..b...b.....b...b..",
+		"b.................b",
+		"....b..b...b..b....",
+		".....b.......b.....",
+		"...b..b.....b..b...",
+	)
+
+	Variants = []*Variant{Brandubh, Tablut, Copenhagen, Hnefatafl13, AleaEvangelii}
+)
+
+// NewVariant builds a variant from its starting position, one string per
+// row using b for black pawns, w for white pawns, k for the king and any
+// other rune for an empty square.
+func NewVariant(id string, name string, rules RuleSet, setup ...string) (*Variant, error) {
+	size := len(setup)
+	last := size - 1
+
+	if size == 0 || size > MaxSquaresPerRow {
+		return nil, fmt.Errorf("variant %v: board must be between 1x1 and %vx%v", id, MaxSquaresPerRow, MaxSquaresPerRow)
+	}
+
+	v := &Variant{
+		ID:      id,
+		Name:    name,
+		Size:    size,
+		Setup:   setup,
+		Throne:  Coord{Row: size / 2, Col: size / 2},
+		Corners: []Coord{{0, 0}, {0, last}, {last, 0}, {last, last}},
+		Rules:   rules,
+	}
+
+	g := board.GeometryFor(size)
+	v.throne.Set(g.Index(v.Throne.Row, v.Throne.Col))
+
+	for _, c := range v.Corners {
+		v.corners.Set(g.Index(c.Row, c.Col))
+	}
+
+	for _, row := range setup {
+		if len(row) != size {
+			return nil, fmt.Errorf("variant %v: setup is not square", id)
+		}
+
+		v.BlackPawns += uint8(strings.Count(row, "b"))
+		v.WhitePawns += uint8(strings.Count(row, "w"))
+	}
+
+	return v, nil
+}
+
+func newVariant(id string, name string, rules RuleSet, setup ...string) *Variant {
+	v, err := NewVariant(id, name, rules, setup...)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+func VariantByID(id string) (*Variant, bool) {
+	for _, v := range Variants {
+		if strings.EqualFold(v.ID, id) {
+			return v, true
+		}
+	}
+
+	return nil, false
+}
+
+func (v *Variant) String() string {
+	return fmt.Sprintf("%v (%vx%v)", v.Name, v.Size, v.Size)
+}
@@ -1,13 +1,11 @@
 package square
 
 import (
-	raylib "github.com/gen2brain/raylib-go/raylib"
 	piece "github.com/technologyfreak/hnefatafl/piece"
 )
 
 const (
-	SquaresPerRow = 11
-	SquareSize    = 32
+	SquareSize = 32
 )
 
 type Square struct {
@@ -18,26 +16,14 @@ type Square struct {
 }
 
 func ToRowOrCol(i int32) int32 {
-	i -= i % SquareSize
-
-	if i != 0 && i%SquaresPerRow == 0 {
+	if i < 0 {
 		return -1
 	}
 
-	i %= SquaresPerRow
-
-	if i != 0 {
-		i = SquaresPerRow - i
-	}
-
-	return i
-}
-
-func InRowRange(n int32) bool {
-	return n >= 0 && n < SquaresPerRow
+	return i / SquareSize
 }
 
-func NewSquare(bandColor raylib.Color, x int32, y int32) Square {
+func NewSquare(x int32, y int32) Square {
 	return Square{Piece: piece.None, X: x, Y: y}
 }
 
@@ -68,17 +54,3 @@ func (s1 *Square) IsNorthOf(s2 *Square) bool {
 func (s1 *Square) IsSouthOf(s2 *Square) bool {
 	return (s1.Y - s2.Y) > 0
 }
-
-func (s *Square) IsCenter() bool {
-	return ToRowOrCol(s.X) == 5 && ToRowOrCol(s.Y) == 5
-}
-
-func (s *Square) IsKingsCorner() bool {
-	x := ToRowOrCol(s.X)
-	y := ToRowOrCol(s.Y)
-
-	return (x == 0 && y == 0) ||
-		(x == 0 && y == 10) ||
-		(x == 10 && y == 0) ||
-		(x == 10 && y == 10)
-}
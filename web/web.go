@@ -0,0 +1,48 @@
+// Package web serves a browser client for the game server.
+package web
+
+import (
+	_ "embed"
+	"net/http"
+
+	netplay "github.com/technologyfreak/hnefatafl/netplay"
+	resources "github.com/technologyfreak/hnefatafl/resources"
+)
+
+//go:embed index.html
+var index []byte
+
+var images = map[string][]byte{
+	"/images/board.png": resources.BoardBackground,
+	"/images/black.png": resources.BlackPawnSprite,
+	"/images/white.png": resources.WhitePawnSprite,
+	"/images/king.png":  resources.KingSprite,
+}
+
+// Handler serves the client at / and plays games on s through /ws.
+func Handler(s *netplay.Server) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/ws", s.ServeWebSocket)
+
+	for path, data := range images {
+		data := data
+
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(data)
+		})
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})
+
+	return mux
+}
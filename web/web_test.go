@@ -0,0 +1,48 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	netplay "github.com/technologyfreak/hnefatafl/netplay"
+)
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(Handler(&netplay.Server{}))
+	defer srv.Close()
+
+	tests := []struct {
+		path, contentType string
+		status            int
+	}{
+		{"/", "text/html; charset=utf-8", http.StatusOK},
+		{"/images/king.png", "image/png", http.StatusOK},
+		{"/images/queen.png", "", http.StatusNotFound},
+		{"/ws", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%v: got %v", tt.path, resp.Status)
+		}
+
+		if tt.contentType != "" && resp.Header.Get("Content-Type") != tt.contentType {
+			t.Errorf("%v: got %v", tt.path, resp.Header.Get("Content-Type"))
+		}
+
+		if tt.path == "/" && !strings.Contains(string(body), "<canvas") {
+			t.Errorf("/ does not serve the client")
+		}
+	}
+}
@@ -0,0 +1,365 @@
+// Package websocket is just enough of RFC 6455 to carry text messages
+// between a browser and the game server.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MaxMessageSize is the longest message ReadMessage accepts.
+const MaxMessageSize = 1 << 20
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// Status codes sent when closing a connection whose other end broke the
+// protocol.
+const (
+	closeProtocolError = 1002
+	closeTooLarge      = 1009
+)
+
+var (
+	ErrNotWebSocket    = errors.New("websocket: not a websocket handshake")
+	ErrCrossOrigin     = errors.New("websocket: request from another origin")
+	ErrMessageTooLarge = errors.New("websocket: message too large")
+	ErrBadFrame        = errors.New("websocket: bad frame")
+)
+
+// Conn is a websocket connection. One goroutine may read while others
+// write.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	// client connections mask what they send, as the protocol requires.
+	client bool
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sameOrigin reports whether a browser sent r from a page served by the
+// host r is for. Requests that are not from a browser carry no Origin.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Upgrade takes over the connection of a websocket handshake request. On
+// failure it has already answered the request. Browsers may only connect
+// from pages served by the same host, so other sites cannot use a
+// visitor's browser to play.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+
+	if r.Method != http.MethodGet || key == "" ||
+		!headerHas(r.Header, "Connection", "upgrade") ||
+		!headerHas(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, ErrNotWebSocket.Error(), http.StatusBadRequest)
+		return nil, ErrNotWebSocket
+	}
+
+	if !sameOrigin(r) {
+		http.Error(w, ErrCrossOrigin.Error(), http.StatusForbidden)
+		return nil, ErrCrossOrigin
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket: cannot take over the connection", http.StatusInternalServerError)
+		return nil, ErrNotWebSocket
+	}
+
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %v\r\n\r\n", acceptKey(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Conn{conn: conn, r: rw.Reader}, nil
+}
+
+// Dial opens a websocket to a ws:// URL.
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("websocket: cannot dial %v", rawURL)
+	}
+
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	fmt.Fprintf(conn, "GET %v HTTP/1.1\r\nHost: %v\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: %v\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+
+	r := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: %v refused the handshake: %v", rawURL, resp.Status)
+	}
+
+	return &Conn{conn: conn, r: r, client: true}, nil
+}
+
+// ReadMessage returns the next text or binary message, answering pings
+// on the way. It returns io.EOF once the other end closes, and closes the
+// connection itself when the other end breaks the protocol.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	message, err := c.readMessage()
+
+	switch err {
+	case ErrBadFrame:
+		c.fail(closeProtocolError)
+	case ErrMessageTooLarge:
+		c.fail(closeTooLarge)
+	}
+
+	return message, err
+}
+
+func (c *Conn) readMessage() ([]byte, error) {
+	var message []byte
+	started := false
+
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+			if started == (op != opContinuation) {
+				return nil, ErrBadFrame
+			}
+
+			started = true
+
+			if len(message)+len(payload) > MaxMessageSize {
+				return nil, ErrMessageTooLarge
+			}
+
+			message = append(message, payload...)
+
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, ErrBadFrame
+		}
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.r, head[:]); err != nil {
+		return
+	}
+
+	fin, op = head[0]&0x80 != 0, head[0]&0x0f
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7f)
+
+	// No extensions are agreed, so the reserved bits stay clear. Clients
+	// mask every frame and servers none, and control frames are short and
+	// never fragmented.
+	if head[0]&0x70 != 0 || masked == c.client || (op&0x8 != 0 && (!fin || n > 125)) {
+		err = ErrBadFrame
+		return
+	}
+
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if n > MaxMessageSize {
+		err = ErrMessageTooLarge
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.r, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return
+}
+
+// WriteMessage sends p as a single text message.
+func (c *Conn) WriteMessage(p []byte) error {
+	return c.writeFrame(opText, p)
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	frame := []byte{0x80 | op}
+	n := len(payload)
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+
+	switch {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+
+		start := len(frame)
+		frame = append(frame, payload...)
+
+		for i := range frame[start:] {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	if op == opClose {
+		c.closed = true
+	}
+
+	_, err := c.conn.Write(frame)
+
+	return err
+}
+
+// fail closes the connection with the status code for the way the other
+// end broke the protocol.
+func (c *Conn) fail(code uint16) {
+	c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, code))
+	c.conn.Close()
+}
+
+// SetWriteDeadline limits how long writes may block, as for a net.Conn.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Close says goodbye to the other end and closes the connection.
+func (c *Conn) Close() error {
+	c.writeFrame(opClose, nil)
+
+	return c.conn.Close()
+}
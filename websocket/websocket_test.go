@@ -0,0 +1,181 @@
+package websocket
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func echo(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		for {
+			m, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			c.WriteMessage(m)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestEcho(t *testing.T) {
+	c, err := Dial(echo(t) + "/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Sizes that need each of the three length encodings.
+	for _, n := range []int{5, 300, 70000} {
+		sent := bytes.Repeat([]byte("x"), n)
+
+		if err := c.WriteMessage(sent); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(got, sent) {
+			t.Errorf("sent %v bytes, got %v back", n, len(got))
+		}
+	}
+}
+
+func TestPingAndFragments(t *testing.T) {
+	c, err := Dial(echo(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// A ping in the middle of a fragmented message is answered at once.
+	frames := []struct {
+		op      byte
+		fin     bool
+		payload string
+	}{
+		{opText, false, "hel"},
+		{opPing, true, "are you there"},
+		{opContinuation, true, "lo"},
+	}
+
+	for _, f := range frames {
+		head := f.op
+		if f.fin {
+			head |= 0x80
+		}
+
+		frame := append([]byte{head, 0x80 | byte(len(f.payload)), 0, 0, 0, 0}, f.payload...)
+		if _, err := c.conn.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fin, op, payload, err := c.readFrame()
+	if err != nil || !fin || op != opPong || string(payload) != "are you there" {
+		t.Fatalf("got %v %v %q %v, want the pong", fin, op, payload, err)
+	}
+
+	got, err := c.ReadMessage()
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	c.writeFrame(opClose, nil)
+	if _, err := c.ReadMessage(); err != io.EOF {
+		t.Errorf("got %v after closing, want EOF", err)
+	}
+}
+
+func TestUpgradeRefusesPlainRequests(t *testing.T) {
+	url := echo(t)
+
+	resp, err := http.Get("http" + strings.TrimPrefix(url, "ws"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got %v", resp.Status)
+	}
+}
+
+func TestUpgradeChecksOrigin(t *testing.T) {
+	url := "http" + strings.TrimPrefix(echo(t), "ws")
+
+	for origin, want := range map[string]int{
+		"":                           http.StatusSwitchingProtocols,
+		url:                          http.StatusSwitchingProtocols,
+		"http://elsewhere.example":   http.StatusForbidden,
+		"http://elsewhere.example:1": http.StatusForbidden,
+	} {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != want {
+			t.Errorf("origin %q: got %v, want %v", origin, resp.Status, want)
+		}
+	}
+}
+
+func TestBadFramesClose(t *testing.T) {
+	mask := []byte{1, 2, 3, 4}
+
+	for name, frame := range map[string][]byte{
+		"unmasked":         {0x80 | opText, 2, 'h', 'i'},
+		"reserved bit":     append([]byte{0xc0 | opText, 0x80}, mask...),
+		"long ping":        append(append([]byte{0x80 | opPing, 0x80 | 126, 0, 126}, mask...), make([]byte, 126)...),
+		"fragmented close": append([]byte{opClose, 0x80}, mask...),
+	} {
+		c, err := Dial(echo(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := c.conn.Write(frame); err != nil {
+			t.Fatal(err)
+		}
+
+		_, op, payload, err := c.readFrame()
+		if err != nil || op != opClose || !bytes.Equal(payload, []byte{0x03, 0xea}) {
+			t.Errorf("%v: got %v %v %v, want a close with 1002", name, op, payload, err)
+		}
+
+		c.conn.Close()
+	}
+}